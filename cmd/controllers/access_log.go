@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+	"server/model"
+)
+
+// requestUser определяет пользователя запроса: заголовок X-User или IP клиента
+func requestUser(c *gin.Context) string {
+	if user := c.GetHeader("X-User"); user != "" {
+		return user
+	}
+	return c.ClientIP()
+}
+
+// logTableRead записывает чтение таблицы в журнал доступа, если таблица помечена как чувствительная.
+// Ошибки журнала не прерывают запрос
+func logTableRead(c *gin.Context, tableName, action string, rowCount int) {
+	var meta model.TableMeta
+	if err := getDB(c).Where("name = ?", tableName).First(&meta).Error; err != nil || !meta.Sensitive {
+		return
+	}
+
+	entry := model.AccessLog{
+		User:      requestUser(c),
+		TableName: tableName,
+		Action:    action,
+		RowCount:  rowCount,
+	}
+	if err := getDB(c).Create(&entry).Error; err != nil {
+		log.Println("Failed to write access log: ", err)
+	}
+}
+
+// SetTableSensitive включает или выключает журналирование чтения таблицы
+func SetTableSensitive(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var req struct {
+		Sensitive *bool `json:"sensitive" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := getDB(c).Model(&model.TableMeta{}).
+		Where("name = ?", tableName).
+		Update("sensitive", *req.Sensitive)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Метаданные таблицы не найдены"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"table":     tableName,
+		"sensitive": *req.Sensitive,
+	})
+}
+
+// GetAccessLog возвращает журнал чтения с фильтрами ?table=, ?user=, ?since= (RFC3339).
+// При заданном TABLE_ALLOWLIST возвращаются только записи разрешенных таблиц, как в ListTables
+func GetAccessLog(c *gin.Context) {
+	db := getDB(c).Model(&model.AccessLog{})
+
+	if cond, condArgs := allowlistCondition("table_name"); cond != "" {
+		db = db.Where(cond, condArgs...)
+	}
+	if table := c.Query("table"); table != "" {
+		if !initializers.TableAllowed(table) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Доступ к таблице запрещен", "table": table})
+			return
+		}
+		db = db.Where("table_name = ?", table)
+	}
+	if user := c.Query("user"); user != "" {
+		db = db.Where("\"user\" = ?", user)
+	}
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since должен быть в формате RFC3339"})
+			return
+		}
+		db = db.Where("created_at >= ?", t)
+	}
+
+	var entries []model.AccessLog
+	if err := db.Order("created_at DESC").Limit(1000).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"server/model"
+)
+
+// TestGetAccessLogAllowlist проверяет, что журнал чтения не показывает записи таблиц
+// вне TABLE_ALLOWLIST, а запрос журнала такой таблицы отклоняется
+func TestGetAccessLogAllowlist(t *testing.T) {
+	db := testDB(t)
+	tables := []string{"test_access_visible", "hidden_access_log"}
+	t.Cleanup(func() { db.Where("table_name IN ?", tables).Delete(&model.AccessLog{}) })
+	for _, table := range tables {
+		if err := db.Create(&model.AccessLog{User: "tester", TableName: table, Action: "read"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	setTestEnv(t, map[string]string{"TABLE_ALLOWLIST": "test_*"})
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.GET("/api/access-log", GetAccessLog)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/access-log?user=tester", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("журнал: %d %s, want 200", w.Code, w.Body.String())
+	}
+	var entries []model.AccessLog
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].TableName != "test_access_visible" {
+		t.Fatalf("записи журнала: %+v, want только test_access_visible", entries)
+	}
+
+	if status, resp := doJSON(t, r, http.MethodGet, "/api/access-log?table=hidden_access_log", nil); status != http.StatusForbidden {
+		t.Fatalf("?table=hidden_access_log: %d %v, want 403", status, resp)
+	}
+}
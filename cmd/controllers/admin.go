@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+// testConnectionTimeout — сколько ждем подключения при проверке параметров
+const testConnectionTimeout = 5 * time.Second
+
+// TestConnection проверяет параметры подключения к БД, не затрагивая текущее подключение
+func TestConnection(c *gin.Context) {
+	var cfg initializers.DBConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if cfg.Host == "" || cfg.User == "" || cfg.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нужно указать host, user и dbname"})
+		return
+	}
+	if cfg.Port == "" {
+		cfg.Port = "5432"
+	}
+
+	start := time.Now()
+	db, err := initializers.Open(cfg, testConnectionTimeout)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"latencyMs": time.Since(start).Milliseconds(),
+	})
+}
+
+// Reconnect переподключается к БД с параметрами из тела запроса или из .env.
+// Глобальное подключение заменяется только после успешного Ping
+func Reconnect(c *gin.Context) {
+	var cfg initializers.DBConfig
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&cfg); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		if err := initializers.ReloadEnv(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения .env", "details": err.Error()})
+			return
+		}
+		var err error
+		if cfg, err = initializers.ConfigFromEnv(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные параметры подключения в .env", "details": err.Error()})
+			return
+		}
+	}
+
+	if cfg.Host == "" || cfg.User == "" || cfg.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нужно указать host, user и dbname"})
+		return
+	}
+	if cfg.Port == "" {
+		cfg.Port = "5432"
+	}
+
+	db, err := initializers.Open(cfg, testConnectionTimeout)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Не удалось подключиться, текущее подключение сохранено",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	initializers.SwapDB(db, cfg)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "Подключение обновлено",
+		"connection": gin.H{
+			"host":    cfg.Host,
+			"port":    cfg.Port,
+			"user":    cfg.User,
+			"dbname":  cfg.Name,
+			"sslmode": cfg.SSLMode,
+		},
+	})
+}
+
+// backendActivity — активный запрос из pg_stat_activity
+type backendActivity struct {
+	PID        int        `gorm:"column:pid" json:"pid"`
+	State      string     `gorm:"column:state" json:"state"`
+	Query      string     `gorm:"column:query" json:"query"`
+	QueryStart *time.Time `gorm:"column:query_start" json:"queryStart"`
+	DurationMs *float64   `gorm:"column:duration_ms" json:"durationMs"`
+}
+
+// appActivityFilter ограничивает pg_stat_activity запросами приложения:
+// текущая база, текущий пользователь, без собственного подключения
+const appActivityFilter = `
+	datname = current_database()
+	AND usename = current_user
+	AND pid <> pg_backend_pid()
+	AND backend_type = 'client backend'`
+
+// GetActivity возвращает активные запросы приложения, самые долгие первыми
+func GetActivity(c *gin.Context) {
+	var activity []backendActivity
+	if err := getDB(c).Raw(`
+		SELECT pid, state, query, query_start,
+			EXTRACT(EPOCH FROM (now() - query_start)) * 1000 AS duration_ms
+		FROM pg_stat_activity
+		WHERE` + appActivityFilter + `
+		AND state <> 'idle'
+		ORDER BY query_start
+	`).Scan(&activity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, activity)
+}
+
+// KillBackend отменяет запрос процесса :pid через pg_cancel_backend,
+// с ?terminate=true — закрывает подключение через pg_terminate_backend
+func KillBackend(c *gin.Context) {
+	pid, err := strconv.Atoi(c.Param("pid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный pid"})
+		return
+	}
+
+	// Разрешаем останавливать только запросы приложения
+	var exists bool
+	if err := getDB(c).Raw(`
+		SELECT EXISTS (
+			SELECT FROM pg_stat_activity
+			WHERE pid = ? AND`+appActivityFilter+`
+		)`, pid).Scan(&exists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Процесс не найден"})
+		return
+	}
+
+	function := "pg_cancel_backend"
+	if c.Query("terminate") == "true" {
+		function = "pg_terminate_backend"
+	}
+
+	var success bool
+	if err := getDB(c).Raw("SELECT "+function+"(?)", pid).Scan(&success).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pid":      pid,
+		"function": function,
+		"success":  success,
+	})
+}
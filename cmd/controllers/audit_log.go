@@ -0,0 +1,331 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// rowChange — изменение одной строки для журнала аудита
+type rowChange struct {
+	RowID   string
+	Changes map[string]interface{}
+}
+
+// logTableChange записывает изменение строки в журнал аудита, если он включен для таблицы.
+// db — подключение или транзакция, в которой выполнено изменение. Ошибки журнала не прерывают запрос
+func logTableChange(db *gorm.DB, c *gin.Context, tableName, operation, rowID string, changes map[string]interface{}) {
+	logTableChanges(db, c, tableName, operation, []rowChange{{RowID: rowID, Changes: changes}})
+}
+
+// logTableChanges записывает несколько изменений одной операции одной вставкой.
+// Массовые операции без списка строк (bulk_update, delete_all, restore, ingest, copy)
+// пишут одну запись без rowId с итогами операции в changes
+func logTableChanges(db *gorm.DB, c *gin.Context, tableName, operation string, changes []rowChange) {
+	if len(changes) == 0 {
+		return
+	}
+	var meta model.TableMeta
+	if err := db.Where("name = ?", tableName).First(&meta).Error; err != nil || !meta.Audit {
+		return
+	}
+
+	user := requestUser(c)
+	entries := make([]model.AuditLog, 0, len(changes))
+	for _, change := range changes {
+		entry := model.AuditLog{
+			User:      user,
+			TableName: tableName,
+			Operation: operation,
+			RowID:     change.RowID,
+		}
+		if change.Changes != nil {
+			data, err := json.Marshal(change.Changes)
+			if err != nil {
+				log.Println("Failed to encode audit changes: ", err)
+				return
+			}
+			entry.Changes = string(data)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := db.CreateInBatches(entries, 500).Error; err != nil {
+		log.Println("Failed to write audit log: ", err)
+	}
+}
+
+// SetTableAudit включает или выключает журнал изменений строк таблицы
+func SetTableAudit(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var req struct {
+		Audit *bool `json:"audit" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := getDB(c).Model(&model.TableMeta{}).
+		Where("name = ?", tableName).
+		Update("audit", *req.Audit)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Метаданные таблицы не найдены"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"table": tableName,
+		"audit": *req.Audit,
+	})
+}
+
+// tableChange — элемент ленты изменений таблицы. Table заполняется в общем журнале (GetAuditLog)
+type tableChange struct {
+	ID        uint                   `json:"id"`
+	Table     string                 `json:"table,omitempty"`
+	Operation string                 `json:"operation"`
+	RowID     string                 `json:"rowId"`
+	Changes   map[string]interface{} `json:"changes"`
+	User      string                 `json:"user"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// GetTableChanges возвращает изменения строк таблицы из журнала аудита по возрастанию времени,
+// начиная с ?since= (RFC3339). Лента всегда постраничная: ?page=&pageSize=
+func GetTableChanges(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var meta model.TableMeta
+	if err := getDB(c).Where("name = ?", tableName).First(&meta).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Метаданные таблицы не найдены"})
+		return
+	}
+	if !meta.Audit {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Журнал изменений для таблицы выключен",
+			"hint":  "Включите его через PUT /api/tables/" + tableName + "/audit",
+		})
+		return
+	}
+
+	db := getDB(c).Model(&model.AuditLog{}).Where("table_name = ?", tableName)
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since должен быть в формате RFC3339"})
+			return
+		}
+		db = db.Where("created_at >= ?", t)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	p := parsePagination(c)
+
+	var entries []model.AuditLog
+	if err := db.Order("created_at, id").Offset(p.Offset()).Limit(p.PageSize).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	changes := make([]tableChange, 0, len(entries))
+	for _, entry := range entries {
+		changes = append(changes, newTableChange(entry))
+	}
+
+	c.JSON(http.StatusOK, paginatedResponse(changes, p, total))
+}
+
+// newTableChange разбирает запись журнала аудита
+func newTableChange(entry model.AuditLog) tableChange {
+	change := tableChange{
+		ID:        entry.ID,
+		Operation: entry.Operation,
+		RowID:     entry.RowID,
+		User:      entry.User,
+		CreatedAt: entry.CreatedAt,
+	}
+	if entry.Changes != "" {
+		json.Unmarshal([]byte(entry.Changes), &change.Changes)
+	}
+	return change
+}
+
+// auditCursor — позиция в журнале аудита для постраничного чтения от новых записей к старым
+type auditCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// encode упаковывает курсор в строку для ?cursor=
+func (cur auditCursor) encode() string {
+	raw := fmt.Sprintf("%s,%d", cur.CreatedAt.UTC().Format(time.RFC3339Nano), cur.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// parseAuditCursor разбирает курсор из nextCursor предыдущей страницы
+func parseAuditCursor(s string) (auditCursor, error) {
+	invalid := fmt.Errorf("некорректный cursor, используйте nextCursor из предыдущего ответа")
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return auditCursor{}, invalid
+	}
+	at, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return auditCursor{}, invalid
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return auditCursor{}, invalid
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return auditCursor{}, invalid
+	}
+	return auditCursor{CreatedAt: createdAt, ID: uint(n)}, nil
+}
+
+// auditCSVHeaders — колонки выгрузки журнала аудита в CSV
+var auditCSVHeaders = []string{"id", "createdAt", "user", "table", "operation", "rowId", "changes"}
+
+// GetAuditLog возвращает журнал аудита всех таблиц от новых записей к старым.
+// Фильтры: ?table=, ?operation=, ?user=, ?from=&to= (RFC3339, включительно).
+// Страницы — по курсору: ?pageSize= (не больше maxPageSize) и ?cursor= из nextCursor.
+// С ?format=csv все подходящие записи (не больше EXPORT_MAX_ROWS или ?maxRows=) выгружаются в CSV
+func GetAuditLog(c *gin.Context) {
+	db := getDB(c).Model(&model.AuditLog{})
+
+	if table := c.Query("table"); table != "" {
+		db = db.Where("table_name = ?", table)
+	}
+	if operation := c.Query("operation"); operation != "" {
+		db = db.Where("operation = ?", operation)
+	}
+	if user := c.Query("user"); user != "" {
+		db = db.Where("\"user\" = ?", user)
+	}
+	for _, bound := range []fieldBound{{"from", ">="}, {"to", "<="}} {
+		raw := c.Query(bound.param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": bound.param + " должен быть в формате RFC3339"})
+			return
+		}
+		db = db.Where("created_at "+bound.op+" ?", t)
+	}
+
+	db = db.Order("created_at DESC, id DESC")
+
+	if c.Query("format") == "csv" {
+		exportAuditLog(c, db)
+		return
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := parseAuditCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		db = db.Where("(created_at, id) < (?, ?)", cur.CreatedAt, cur.ID)
+	}
+
+	// Читаем на одну запись больше, чтобы узнать, есть ли следующая страница
+	pageSize := parsePagination(c).PageSize
+	var entries []model.AuditLog
+	if err := db.Limit(pageSize + 1).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var nextCursor *string
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		last := entries[len(entries)-1]
+		next := auditCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+		nextCursor = &next
+	}
+
+	changes := make([]tableChange, 0, len(entries))
+	for _, entry := range entries {
+		change := newTableChange(entry)
+		change.Table = entry.TableName
+		changes = append(changes, change)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       changes,
+		"pageSize":   pageSize,
+		"nextCursor": nextCursor,
+	})
+}
+
+// exportAuditLog выгружает записи журнала из db в CSV с параметрами формата как у ExportTable
+func exportAuditLog(c *gin.Context, db *gorm.DB) {
+	format, err := parseExportFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := format.checkColumns(auditCSVHeaders); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "columns": auditCSVHeaders})
+		return
+	}
+
+	var entries []model.AuditLog
+	if err := db.Limit(format.MaxRows + 1).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", attachmentDisposition("audit_log.csv"))
+	if len(entries) > format.MaxRows {
+		entries = entries[:format.MaxRows]
+		format.markTruncated(c.Writer)
+	}
+
+	headers := format.selectColumns(auditCSVHeaders)
+	writer := format.Dialect.newWriter(c.Writer)
+	defer writer.Flush()
+	writer.Write(format.headers(headers))
+
+	for _, entry := range entries {
+		row := map[string]interface{}{
+			"id":        entry.ID,
+			"createdAt": entry.CreatedAt,
+			"user":      entry.User,
+			"table":     entry.TableName,
+			"operation": entry.Operation,
+			"rowId":     entry.RowID,
+			"changes":   entry.Changes,
+		}
+		values := make([]string, 0, len(headers))
+		for _, h := range headers {
+			values = append(values, format.formatValue(row[h], ""))
+		}
+		writer.Write(values)
+	}
+}
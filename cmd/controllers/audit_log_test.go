@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"server/model"
+)
+
+// TestBulkWritesAudited проверяет, что массовые операции попадают в журнал аудита
+// и ленту изменений таблицы
+func TestBulkWritesAudited(t *testing.T) {
+	db := testDB(t)
+	setTestEnv(t, nil)
+	dropTestTable(t, db, "test_bulk_audit")
+	t.Cleanup(func() { db.Where("table_name = ?", "test_bulk_audit").Delete(&model.AuditLog{}) })
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.POST("/api/tables", CreateTable)
+		r.PUT("/api/tables/:name/audit", SetTableAudit)
+		r.POST("/api/tables/:name/rows/bulk", BulkInsertRows)
+		r.POST("/api/tables/:name/rows/bulk-update", BulkUpdateRows)
+		r.DELETE("/api/tables/:name/rows", DeleteAllRows)
+	})
+
+	steps := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodPost, "/api/tables", gin.H{"name": "test_bulk_audit", "columns": []string{"code:INTEGER:pk", "title:TEXT"}}},
+		{http.MethodPut, "/api/tables/test_bulk_audit/audit", gin.H{"audit": true}},
+		{http.MethodPost, "/api/tables/test_bulk_audit/rows/bulk", []gin.H{{"code": 1, "title": "a"}, {"code": 2, "title": "b"}}},
+		{http.MethodPost, "/api/tables/test_bulk_audit/rows/bulk-update", gin.H{"set": gin.H{"title": "c"}, "filter": "code:gt:0"}},
+		{http.MethodDelete, "/api/tables/test_bulk_audit/rows?all=true", nil},
+	}
+	for _, step := range steps {
+		if status, resp := doJSON(t, r, step.method, step.path, step.body); status != http.StatusOK && status != http.StatusCreated {
+			t.Fatalf("%s %s: %d %v", step.method, step.path, status, resp)
+		}
+	}
+
+	var entries []model.AuditLog
+	if err := db.Where("table_name = ?", "test_bulk_audit").Order("id").Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Operation+":"+entry.RowID)
+	}
+	want := []string{"insert:1", "insert:2", "bulk_update:", "delete_all:"}
+	if len(got) != len(want) {
+		t.Fatalf("журнал = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("журнал = %v, want %v", got, want)
+		}
+	}
+}
+
+// TestBulkInsertAuditGeneratedKey проверяет, что для строк без переданного ключа в журнал
+// аудита попадает ключ, сгенерированный SERIAL
+func TestBulkInsertAuditGeneratedKey(t *testing.T) {
+	db := testDB(t)
+	setTestEnv(t, nil)
+	dropTestTable(t, db, "test_bulk_serial")
+	t.Cleanup(func() { db.Where("table_name = ?", "test_bulk_serial").Delete(&model.AuditLog{}) })
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.POST("/api/tables", CreateTable)
+		r.PUT("/api/tables/:name/audit", SetTableAudit)
+		r.POST("/api/tables/:name/rows/bulk", BulkInsertRows)
+	})
+
+	steps := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodPost, "/api/tables", gin.H{"name": "test_bulk_serial", "columns": []string{"title:TEXT"}}},
+		{http.MethodPut, "/api/tables/test_bulk_serial/audit", gin.H{"audit": true}},
+		{http.MethodPost, "/api/tables/test_bulk_serial/rows/bulk", []gin.H{{"title": "a"}, {"title": "b"}}},
+	}
+	for _, step := range steps {
+		if status, resp := doJSON(t, r, step.method, step.path, step.body); status != http.StatusOK && status != http.StatusCreated {
+			t.Fatalf("%s %s: %d %v", step.method, step.path, status, resp)
+		}
+	}
+
+	var rowIDs []string
+	if err := db.Model(&model.AuditLog{}).Where("table_name = ?", "test_bulk_serial").Order("id").Pluck("row_id", &rowIDs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rowIDs) != 2 || rowIDs[0] != "1" || rowIDs[1] != "2" {
+		t.Fatalf("rowId в журнале = %q, want [1 2]", rowIDs)
+	}
+}
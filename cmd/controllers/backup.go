@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// writeBackupArchive пишет zip-архив с CSV каждой таблицы и _metadata.json,
+// содержащим метаданные только этих таблиц. При schemaOnly вместо <таблица>.csv
+// пишется <таблица>.sql с CREATE TABLE и индексами, данные не выгружаются
+func writeBackupArchive(db *gorm.DB, w io.Writer, tables []string, schemaOnly bool) error {
+	zipWriter := zip.NewWriter(w)
+
+	for _, table := range tables {
+		if schemaOnly {
+			ddl, _, err := buildTableDDL(db, table)
+			if err != nil {
+				return err
+			}
+			file, err := zipWriter.Create(table + ".sql")
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(file, ddl); err != nil {
+				return err
+			}
+			continue
+		}
+
+		file, err := zipWriter.Create(table + ".csv")
+		if err != nil {
+			return err
+		}
+		if _, err := exportTableToWriter(db, table, file, exportFormat{}); err != nil {
+			return err
+		}
+	}
+
+	var metas []model.TableMeta
+	if err := db.Where("name IN ?", tables).Find(&metas).Error; err != nil {
+		return err
+	}
+	metaData, err := json.Marshal(metas)
+	if err != nil {
+		return err
+	}
+	file, err := zipWriter.Create("_metadata.json")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(metaData); err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+// streamBackupArchive отдает архив клиенту по мере записи, без временного файла.
+// После начала передачи ошибку можно только записать в лог
+func streamBackupArchive(c *gin.Context, filename string, tables []string, schemaOnly bool) {
+	c.Header("Content-Disposition", attachmentDisposition(filename))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	if err := writeBackupArchive(getDB(c), c.Writer, tables, schemaOnly); err != nil {
+		log.Println("Failed to write backup archive: ", err)
+	}
+}
+
+// Запросы, которые buildTableDDL пишет в <таблица>.sql; другие в архиве не принимаются
+var (
+	ddlIdentifier         = `("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*)`
+	createTablePattern    = regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+` + ddlIdentifier + `\s*(\(.*\))$`)
+	createIndexPattern    = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:IF\s+NOT\s+EXISTS\s+)?` + ddlIdentifier + `\s+ON\s+(?:ONLY\s+)?(?:public\.)?` + ddlIdentifier + `\s+USING\s+\w+\s*(\(.*\))$`)
+	createSequencePattern = regexp.MustCompile(`(?is)^CREATE\s+SEQUENCE\s+IF\s+NOT\s+EXISTS\s+(\S+)$`)
+	ownedSequencePattern  = regexp.MustCompile(`(?is)^ALTER\s+SEQUENCE\s+(\S+)\s+OWNED\s+BY\s+` + ddlIdentifier + `\.` + ddlIdentifier + `$`)
+)
+
+// isTableIdentifier сообщает, что ident из DDL — имя таблицы table (в кавычках или без)
+func isTableIdentifier(ident, table string) bool {
+	return ident == quoteIdentifier(table) || ident == table
+}
+
+// isParenthesized проверяет, что s — одно выражение в скобках: скобка, открытая первым
+// символом, закрывается последним. Скобки в литералах и идентификаторах в кавычках не считаются,
+// комментарии не допускаются — так после списка колонок нельзя дописать AS SELECT и т. п.
+func isParenthesized(s string) bool {
+	if !strings.HasPrefix(s, "(") {
+		return false
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(s[i+1:], ch)
+			if end < 0 {
+				return false
+			}
+			i += end + 1
+		case ch == '-' && strings.HasPrefix(s[i:], "--"), ch == '/' && strings.HasPrefix(s[i:], "/*"):
+			return false
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+			if depth == 0 && i != len(s)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// schemaStatements разбирает <таблица>.sql из schema-only архива и возвращает его запросы.
+// Допускаются только запросы, которые пишет buildTableDDL для этой же таблицы: один
+// CREATE TABLE, CREATE INDEX ... ON по ней и последовательности ее SERIAL-колонок.
+// Любой другой запрос — ошибка, чтобы через архив нельзя было выполнить произвольный SQL
+func schemaStatements(ddl, tableName string) ([]string, error) {
+	statements := splitStatements(ddl)
+
+	var createTable string
+	for _, stmt := range statements {
+		stmt = stripLeadingComments(stmt)
+		if m := createTablePattern.FindStringSubmatch(stmt); m != nil {
+			if !isTableIdentifier(m[1], tableName) {
+				return nil, fmt.Errorf("CREATE TABLE создает таблицу %s вместо %s", m[1], quoteIdentifier(tableName))
+			}
+			if createTable != "" {
+				return nil, fmt.Errorf("в файле больше одного CREATE TABLE")
+			}
+			if !isParenthesized(m[2]) {
+				return nil, fmt.Errorf("недопустимое определение таблицы %s", quoteIdentifier(tableName))
+			}
+			createTable = stmt
+		}
+	}
+	if createTable == "" {
+		return nil, fmt.Errorf("в файле нет CREATE TABLE %s", quoteIdentifier(tableName))
+	}
+
+	// Последовательности допустимы только те, что используются в DEFAULT колонок таблицы
+	sequences := map[string]bool{}
+	for _, m := range nextvalPattern.FindAllStringSubmatch(createTable, -1) {
+		sequences[m[1]] = true
+	}
+
+	result := make([]string, 0, len(statements))
+	for _, stmt := range statements {
+		stmt = stripLeadingComments(stmt)
+		if stmt == "" {
+			continue
+		}
+		allowed := stmt == createTable
+		if m := createIndexPattern.FindStringSubmatch(stmt); m != nil {
+			allowed = isTableIdentifier(m[2], tableName) && isParenthesized(m[3])
+		}
+		if m := createSequencePattern.FindStringSubmatch(stmt); m != nil {
+			allowed = sequences[m[1]]
+		}
+		if m := ownedSequencePattern.FindStringSubmatch(stmt); m != nil {
+			allowed = sequences[m[1]] && isTableIdentifier(m[2], tableName)
+		}
+		if !allowed {
+			return nil, fmt.Errorf("недопустимый запрос в схеме таблицы %s: %s", quoteIdentifier(tableName), truncateSQL(stmt))
+		}
+		result = append(result, stmt)
+	}
+	return result, nil
+}
+
+// truncateSQL сокращает запрос для сообщения об ошибке
+func truncateSQL(stmt string) string {
+	if runes := []rune(stmt); len(runes) > 100 {
+		return string(runes[:100]) + "..."
+	}
+	return stmt
+}
+
+// restoreSchemaFromZip пересоздает пустую таблицу по DDL из schema-only архива.
+// DDL проверяется schemaStatements до удаления существующей таблицы
+func restoreSchemaFromZip(tx *gorm.DB, zipFile *zip.File, tableName string) error {
+	rc, err := zipFile.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	ddl, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+
+	statements, err := schemaStatements(string(ddl), tableName)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", quoteIdentifier(tableName))).Error; err != nil {
+		return err
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackupTables выгружает в один архив только указанные таблицы (?schemaOnly=true — только структуру)
+func BackupTables(c *gin.Context) {
+	var req struct {
+		Tables []string `json:"tables" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if forbidden := forbiddenTables(req.Tables); len(forbidden) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Доступ к таблицам запрещен", "tables": forbidden})
+		return
+	}
+
+	var missing []string
+	for _, table := range req.Tables {
+		exists, err := tableExists(getDB(c), table)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки таблицы"})
+			return
+		}
+		if !exists {
+			missing = append(missing, table)
+		}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Таблицы не найдены",
+			"missing": missing,
+		})
+		return
+	}
+
+	streamBackupArchive(c, "tables_backup.zip", req.Tables, c.Query("schemaOnly") == "true")
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import "testing"
+
+func TestSchemaStatements(t *testing.T) {
+	const table = `CREATE TABLE "items" ("id" integer NOT NULL DEFAULT nextval('items_id_seq'::regclass), "name" varchar(20) DEFAULT 'a)b', PRIMARY KEY ("id"))`
+
+	valid := "CREATE SEQUENCE IF NOT EXISTS items_id_seq;\n" +
+		table + ";\n" +
+		`ALTER SEQUENCE items_id_seq OWNED BY "items"."id";` + "\n" +
+		"CREATE UNIQUE INDEX items_name_key ON public.items USING btree (name);\n"
+	statements, err := schemaStatements(valid, "items")
+	if err != nil {
+		t.Fatalf("DDL из экспорта отклонен: %v", err)
+	}
+	if len(statements) != 4 {
+		t.Fatalf("запросов: %d, want 4", len(statements))
+	}
+
+	rejected := map[string]string{
+		"нет CREATE TABLE":         "CREATE INDEX i ON items USING btree (name);",
+		"другая таблица":           `CREATE TABLE "other" ("id" integer);`,
+		"два CREATE TABLE":         table + ";" + table + ";",
+		"AS SELECT":                `CREATE TABLE "items" ("id") AS SELECT pg_sleep(1);`,
+		"комментарий в теле":       `CREATE TABLE "items" ("id" integer /* ) */);`,
+		"посторонний запрос":       table + "; DROP TABLE users;",
+		"индекс другой таблицы":    table + "; CREATE INDEX i ON users USING btree (name);",
+		"чужая последовательность": table + "; CREATE SEQUENCE IF NOT EXISTS other_seq;",
+		"DML после таблицы":        table + "; INSERT INTO items (id) VALUES (1);",
+	}
+	for title, ddl := range rejected {
+		if _, err := schemaStatements(ddl, "items"); err == nil {
+			t.Errorf("%s: DDL принят, want ошибку", title)
+		}
+	}
+}
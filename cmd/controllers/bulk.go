@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rowError описывает строку, не прошедшую проверку при массовой вставке
+type rowError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+// coerceRow проверяет и приводит значения строки к типам колонок
+func coerceRow(row map[string]interface{}, types map[string]string) (map[string]interface{}, string, error) {
+	result := make(map[string]interface{}, len(row))
+	for field, val := range row {
+		dataType, ok := types[field]
+		if !ok {
+			return nil, field, fmt.Errorf("колонка '%s' не существует", field)
+		}
+
+		coerced, err := coerceInput(val, dataType)
+		if err != nil {
+			return nil, field, err
+		}
+		result[field] = coerced
+	}
+	return result, "", nil
+}
+
+// BulkInsertRows вставляет массив строк. Все строки проверяются по схеме до записи в БД;
+// с ?skipInvalid=true некорректные строки пропускаются и возвращаются в отчете
+func BulkInsertRows(c *gin.Context) {
+	tableName := c.Param("name")
+	skipInvalid := c.Query("skipInvalid") == "true"
+
+	var rows []map[string]interface{}
+	if err := c.ShouldBindJSON(&rows); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нет строк для вставки"})
+		return
+	}
+
+	// Типы колонок получаем один раз на весь пакет
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(columnTypes) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		types[col.ColumnName] = col.DataType
+	}
+
+	valid := make([]map[string]interface{}, 0, len(rows))
+	validIndex := make([]int, 0, len(rows))
+	var rejected []rowError
+	for i, row := range rows {
+		coerced, field, err := coerceRow(row, types)
+		if err != nil {
+			rejected = append(rejected, rowError{Row: i, Field: field, Error: err.Error()})
+			continue
+		}
+		if errs := validateFields(tableName, row); len(errs) > 0 {
+			rejected = append(rejected, rowError{Row: i, Field: errs[0].Field, Error: errs[0].Error})
+			continue
+		}
+		valid = append(valid, coerced)
+		validIndex = append(validIndex, i)
+	}
+
+	if len(rejected) > 0 && !skipInvalid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Некорректные строки, данные не записаны",
+			"rejected": rejected,
+		})
+		return
+	}
+
+	// Идентификатор строки для журнала аудита — значение первичного ключа. Его возвращает
+	// RETURNING, поэтому в журнал попадают и ключи, сгенерированные SERIAL/IDENTITY
+	pkColumn, _ := getPrimaryKeyColumn(getDB(c), tableName)
+
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		changes := make([]rowChange, 0, len(valid))
+		for i, row := range valid {
+			insert := tx.Table(tableName)
+			if pkColumn != "" {
+				insert = insert.Clauses(clause.Returning{Columns: []clause.Column{{Name: pkColumn}}})
+			}
+			if err := insert.Create(row).Error; err != nil {
+				apiErr := dbWriteError(err)
+				apiErr.Body["row"] = validIndex[i]
+				return apiErr
+			}
+
+			var rowID string
+			if pkColumn != "" && row[pkColumn] != nil {
+				rowID = fmt.Sprint(row[pkColumn])
+			}
+			changes = append(changes, rowChange{RowID: rowID, Changes: rows[validIndex[i]]})
+		}
+		logTableChanges(tx, c, tableName, "insert", changes)
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "Строки добавлены",
+		"inserted": len(valid),
+		"rejected": rejected,
+	})
+}
+
+// BulkUpdateRows обновляет колонки из set у всех строк, подходящих под filter (column:op:value).
+// Пустой фильтр запрещен, чтобы случайно не обновить всю таблицу
+func BulkUpdateRows(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var req struct {
+		Set    map[string]interface{} `json:"set" binding:"required"`
+		Filter string                 `json:"filter"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Set) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указаны колонки для обновления"})
+		return
+	}
+	if strings.TrimSpace(req.Filter) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Фильтр обязателен: обновление всей таблицы запрещено"})
+		return
+	}
+
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(columnTypes) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		types[col.ColumnName] = col.DataType
+	}
+
+	set, field, err := coerceRow(req.Set, types)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "column": field})
+		return
+	}
+	if errs := validateFields(tableName, req.Set); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные значения", "fields": errs})
+		return
+	}
+
+	filter, err := parseFilter(req.Filter, types)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updated int64
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		result := tx.Table(tableName).Where(filter.Expr, filter.Args...).Updates(set)
+		if result.Error != nil {
+			return dbWriteError(result.Error)
+		}
+		updated = result.RowsAffected
+
+		logTableChange(tx, c, tableName, "bulk_update", "", map[string]interface{}{
+			"set":     req.Set,
+			"filter":  req.Filter,
+			"updated": updated,
+		})
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "Строки обновлены",
+		"updated": updated,
+	})
+}
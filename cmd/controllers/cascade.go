@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// maxCascadeDepth ограничивает глубину каскадного удаления (и защищает от циклов ссылок
+// между разными таблицами; ссылки таблицы на саму себя обходятся рекурсивным CTE)
+const maxCascadeDepth = 10
+
+// foreignKeyRef — внешний ключ, ссылающийся на таблицу
+type foreignKeyRef struct {
+	ChildTable   string `gorm:"column:child_table"`
+	ChildColumn  string `gorm:"column:child_column"`
+	ParentColumn string `gorm:"column:parent_column"`
+}
+
+// getReferencingKeys возвращает одноколоночные внешние ключи других таблиц, ссылающиеся на tableName
+func getReferencingKeys(db *gorm.DB, tableName string) ([]foreignKeyRef, error) {
+	var refs []foreignKeyRef
+	err := db.Raw(`
+		SELECT child.relname AS child_table, a.attname AS child_column, pa.attname AS parent_column
+		FROM pg_constraint con
+		JOIN pg_class child ON child.oid = con.conrelid
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = con.conkey[1]
+		JOIN pg_attribute pa ON pa.attrelid = con.confrelid AND pa.attnum = con.confkey[1]
+		WHERE con.contype = 'f'
+		AND con.confrelid = ?::regclass
+		AND array_length(con.conkey, 1) = 1
+	`, quoteIdentifier(tableName)).Scan(&refs).Error
+	return refs, err
+}
+
+// cascadeDependents возвращает все таблицы, строки которых может затронуть каскадное удаление
+// из tableName: ссылающиеся на нее напрямую и через другие таблицы. Сама tableName не включается
+func cascadeDependents(db *gorm.DB, tableName string) ([]string, error) {
+	seen := map[string]bool{tableName: true}
+	queue := []string{tableName}
+	var dependents []string
+	for len(queue) > 0 {
+		refs, err := getReferencingKeys(db, queue[0])
+		if err != nil {
+			return nil, err
+		}
+		queue = queue[1:]
+		for _, ref := range refs {
+			if !seen[ref.ChildTable] {
+				seen[ref.ChildTable] = true
+				dependents = append(dependents, ref.ChildTable)
+				queue = append(queue, ref.ChildTable)
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents, nil
+}
+
+// selfReferenceWhere расширяет условие where строк tableName их потомками по внешнему ключу
+// таблицы на саму себя (деревья, иерархии) через рекурсивный CTE. UNION отбрасывает
+// повторы, поэтому циклы ссылок не зацикливают запрос
+func selfReferenceWhere(tableName, where string, ref foreignKeyRef) string {
+	table := quoteIdentifier(tableName)
+	parent := quoteIdentifier(ref.ParentColumn)
+	child := quoteIdentifier(ref.ChildColumn)
+	return fmt.Sprintf(`ctid IN (WITH RECURSIVE tree AS (
+		SELECT ctid AS row_id, %[2]s AS key FROM %[1]s WHERE %[4]s
+		UNION
+		SELECT c.ctid, c.%[2]s FROM %[1]s AS c JOIN tree ON c.%[3]s = tree.key
+	) SELECT row_id FROM tree)`, table, parent, child, where)
+}
+
+// deleteCascade удаляет строки tableName, подходящие под where, вместе со ссылающимися на них
+// строками других таблиц. Количество удаленных строк по таблицам накапливается в deleted
+func deleteCascade(tx *gorm.DB, tableName, where string, args []interface{}, depth int, deleted map[string]int64) error {
+	if depth > maxCascadeDepth {
+		return fmt.Errorf("превышена глубина каскадного удаления (%d)", maxCascadeDepth)
+	}
+
+	refs, err := getReferencingKeys(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	// Строки, ссылающиеся на удаляемые в той же таблице, удаляются вместе с ними
+	for _, ref := range refs {
+		if ref.ChildTable == tableName {
+			where = selfReferenceWhere(tableName, where, ref)
+		}
+	}
+
+	for _, ref := range refs {
+		if ref.ChildTable == tableName {
+			continue
+		}
+		childWhere := fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)",
+			quoteIdentifier(ref.ChildColumn), quoteIdentifier(ref.ParentColumn), quoteIdentifier(tableName), where)
+		if err := deleteCascade(tx, ref.ChildTable, childWhere, args, depth+1, deleted); err != nil {
+			return err
+		}
+	}
+
+	result := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", quoteIdentifier(tableName), where), args...)
+	if result.Error != nil {
+		return result.Error
+	}
+	deleted[tableName] += result.RowsAffected
+	return nil
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDeleteRowForeignKey проверяет удаление строки, на которую ссылается другая таблица:
+// без ?cascade=true — 409 с подсказкой, с ним удаляются и ссылающиеся строки
+func TestDeleteRowForeignKey(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_fk_parent")
+	dropTestTable(t, db, "test_fk_child")
+	for _, sql := range []string{
+		`CREATE TABLE test_fk_parent (id SERIAL PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE test_fk_child (id SERIAL PRIMARY KEY, parent_id INTEGER REFERENCES test_fk_parent (id))`,
+		`INSERT INTO test_fk_parent (name) VALUES ('a'), ('b')`,
+		`INSERT INTO test_fk_child (parent_id) VALUES (1), (1), (2)`,
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.DELETE("/api/tables/:name/rows/:id", DeleteRow)
+	})
+
+	status, resp := doJSON(t, r, http.MethodDelete, "/api/tables/test_fk_parent/rows/1", nil)
+	if status != http.StatusConflict || resp["hint"] == nil {
+		t.Fatalf("без cascade: %d %v, want 409 с hint", status, resp)
+	}
+
+	status, resp = doJSON(t, r, http.MethodDelete, "/api/tables/test_fk_parent/rows/1?cascade=true", nil)
+	if status != http.StatusOK {
+		t.Fatalf("cascade: %d %v", status, resp)
+	}
+	deleted, _ := resp["deleted"].(map[string]interface{})
+	if deleted["test_fk_parent"] != 1.0 || deleted["test_fk_child"] != 2.0 {
+		t.Fatalf("deleted: %v, want test_fk_parent: 1, test_fk_child: 2", resp["deleted"])
+	}
+
+	var remaining int64
+	db.Table("test_fk_child").Count(&remaining)
+	if remaining != 1 {
+		t.Fatalf("в test_fk_child осталось %d строк, want 1", remaining)
+	}
+}
+
+// TestDeleteRowCascadeSelfReference проверяет каскадное удаление в таблице, ссылающейся на себя:
+// удаляется вся ветка дерева, даже если она глубже maxCascadeDepth
+func TestDeleteRowCascadeSelfReference(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_fk_tree")
+	for _, sql := range []string{
+		`CREATE TABLE test_fk_tree (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES test_fk_tree (id))`,
+		`INSERT INTO test_fk_tree VALUES (1, NULL)`,
+		`INSERT INTO test_fk_tree SELECT i, i - 1 FROM generate_series(2, 20) AS i`,
+		`INSERT INTO test_fk_tree VALUES (100, NULL)`,
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.DELETE("/api/tables/:name/rows/:id", DeleteRow)
+	})
+
+	status, resp := doJSON(t, r, http.MethodDelete, "/api/tables/test_fk_tree/rows/1?cascade=true", nil)
+	if status != http.StatusOK {
+		t.Fatalf("cascade: %d %v", status, resp)
+	}
+	deleted, _ := resp["deleted"].(map[string]interface{})
+	if deleted["test_fk_tree"] != 20.0 {
+		t.Fatalf("deleted: %v, want test_fk_tree: 20", resp["deleted"])
+	}
+
+	var remaining int64
+	db.Table("test_fk_tree").Count(&remaining)
+	if remaining != 1 {
+		t.Fatalf("в test_fk_tree осталось %d строк, want 1", remaining)
+	}
+}
+
+// TestDeleteRowCascadeAllowlist проверяет, что каскад не удаляет строки таблиц вне TABLE_ALLOWLIST
+func TestDeleteRowCascadeAllowlist(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_fk_parent")
+	dropTestTable(t, db, "hidden_fk_child")
+	for _, sql := range []string{
+		`CREATE TABLE test_fk_parent (id SERIAL PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE hidden_fk_child (id SERIAL PRIMARY KEY, parent_id INTEGER REFERENCES test_fk_parent (id))`,
+		`INSERT INTO test_fk_parent (name) VALUES ('a')`,
+		`INSERT INTO hidden_fk_child (parent_id) VALUES (1)`,
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	setTestEnv(t, map[string]string{"TABLE_ALLOWLIST": "test_*"})
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.DELETE("/api/tables/:name/rows/:id", DeleteRow)
+	})
+
+	status, resp := doJSON(t, r, http.MethodDelete, "/api/tables/test_fk_parent/rows/1?cascade=true", nil)
+	tables, _ := resp["tables"].([]interface{})
+	if status != http.StatusForbidden || len(tables) != 1 || tables[0] != "hidden_fk_child" {
+		t.Fatalf("cascade: %d %v, want 403 с tables: [hidden_fk_child]", status, resp)
+	}
+
+	var remaining int64
+	db.Table("hidden_fk_child").Count(&remaining)
+	if remaining != 1 {
+		t.Fatalf("в hidden_fk_child осталось %d строк, want 1", remaining)
+	}
+}
+
+// TestDeleteAllRowsForeignKey проверяет, что удаление всех строк таблицы, на которую ссылаются,
+// возвращает 409 с именем внешнего ключа и ничего не удаляет
+func TestDeleteAllRowsForeignKey(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_fk_parent")
+	dropTestTable(t, db, "test_fk_child")
+	for _, sql := range []string{
+		`CREATE TABLE test_fk_parent (id SERIAL PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE test_fk_child (id SERIAL PRIMARY KEY, parent_id INTEGER REFERENCES test_fk_parent (id))`,
+		`INSERT INTO test_fk_parent (name) VALUES ('a'), ('b')`,
+		`INSERT INTO test_fk_child (parent_id) VALUES (1)`,
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.DELETE("/api/tables/:name/rows", DeleteAllRows)
+	})
+
+	status, resp := doJSON(t, r, http.MethodDelete, "/api/tables/test_fk_parent/rows?all=true", nil)
+	if status != http.StatusConflict || resp["constraint"] != "test_fk_child_parent_id_fkey" {
+		t.Fatalf("удаление: %d %v, want 409 с constraint test_fk_child_parent_id_fkey", status, resp)
+	}
+
+	var remaining int64
+	db.Table("test_fk_parent").Count(&remaining)
+	if remaining != 2 {
+		t.Fatalf("в test_fk_parent осталось %d строк, want 2", remaining)
+	}
+}
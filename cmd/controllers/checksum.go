@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTableChecksum считает контрольную сумму данных таблицы: md5 от склеенных md5(row::text)
+// в порядке первичного ключа (без ключа — в порядке текста строк). Одинаковые данные
+// и одинаковый порядок колонок всегда дают одну и ту же сумму, поэтому ее можно сравнивать
+// между окружениями, не выгружая таблицу
+func GetTableChecksum(c *gin.Context) {
+	tableName := c.Param("name")
+
+	if !requireTable(c, tableName) {
+		return
+	}
+
+	orderBy := "t::text"
+	pkColumns, err := getPrimaryKeyColumns(getDB(c), tableName)
+	if err == nil {
+		quoted := make([]string, len(pkColumns))
+		for i, col := range pkColumns {
+			quoted[i] = "t." + quoteIdentifier(col)
+		}
+		orderBy = strings.Join(quoted, ", ")
+	} else {
+		pkColumns = nil
+	}
+
+	var result struct {
+		Rows     int64  `gorm:"column:rows"`
+		Checksum string `gorm:"column:checksum"`
+	}
+	query := fmt.Sprintf(`
+		SELECT count(*) AS rows,
+		       md5(coalesce(string_agg(md5(t::text), '' ORDER BY %s), '')) AS checksum
+		FROM %s t
+	`, orderBy, quoteIdentifier(tableName))
+	if err := getDB(c).Raw(query).Scan(&result).Error; err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"table":      tableName,
+		"algorithm":  "md5",
+		"checksum":   result.Checksum,
+		"rows":       result.Rows,
+		"orderedBy":  pkColumns,
+		"primaryKey": len(pkColumns) > 0,
+	})
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/initializers"
+)
+
+// columnFixResult — что сделано с одной колонкой
+type columnFixResult struct {
+	Table  string `json:"table"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Status string `json:"status"` // "renamed", "merged", "done", "missing"
+}
+
+// FixDomainColumns переименовывает колонки с ошибками в именах (employes_id, year_of_future)
+// в одной транзакции. При старте то же делает Migrate, только если MIGRATE_DOMAIN_COLUMNS=true.
+// ?revert=true возвращает старые имена, ?dryRun=true только показывает план.
+// Для переименованной таблицы создается представление <таблица>_legacy со старыми именами
+// колонок (см. initializers.CreateLegacyView); ?revert=true его удаляет.
+// Если в таблице уже есть обе колонки, значения старой переносятся в пустые ячейки новой,
+// а старая колонка остается — удалить ее можно вручную после проверки
+func FixDomainColumns(c *gin.Context) {
+	revert := c.Query("revert") == "true"
+	dryRun := c.Query("dryRun") == "true"
+
+	var results []columnFixResult
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		for _, fix := range initializers.DomainColumnRenames {
+			table, err := fix.Table(tx)
+			if err != nil {
+				return err
+			}
+
+			from, to := fix.Old, fix.New
+			if revert {
+				from, to = to, from
+			}
+			result := columnFixResult{Table: table, From: from, To: to}
+
+			columns, err := getColumnTypes(tx, table)
+			if err != nil {
+				return err
+			}
+			existing := make(map[string]bool, len(columns))
+			for _, col := range columns {
+				existing[col.ColumnName] = true
+			}
+
+			switch {
+			case existing[from] && !existing[to]:
+				result.Status = "renamed"
+				if !dryRun {
+					err = renameDomainColumn(tx, table, from, to, revert)
+				}
+			case existing[from] && existing[to]:
+				result.Status = "merged"
+				if !dryRun {
+					err = tx.Exec(fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NULL",
+						quoteIdentifier(table), quoteIdentifier(to), quoteIdentifier(from), quoteIdentifier(to))).Error
+				}
+			case existing[to]:
+				result.Status = "done"
+			default:
+				result.Status = "missing"
+			}
+			if err != nil {
+				return newAPIError(http.StatusInternalServerError,
+					fmt.Sprintf("Ошибка переименования %s.%s", table, from), err)
+			}
+
+			results = append(results, result)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	status := "Колонки исправлены"
+	if dryRun {
+		status = "План изменений, данные не изменены"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  status,
+		"revert":  revert,
+		"columns": results,
+	})
+}
+
+// renameDomainColumn переименовывает колонку и обновляет представление <table>_legacy:
+// после переименования в новое имя оно создается, при откате — удаляется
+func renameDomainColumn(tx *gorm.DB, table, from, to string, revert bool) error {
+	if revert {
+		if err := initializers.DropLegacyView(tx, table); err != nil {
+			return err
+		}
+	}
+	if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
+		quoteIdentifier(table), quoteIdentifier(from), quoteIdentifier(to))).Error; err != nil {
+		return err
+	}
+	if revert {
+		return nil
+	}
+	return initializers.CreateLegacyView(tx, table)
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+	"server/initializers"
+	"server/model"
+)
+
+// TestMigrateRenamesDomainColumns проверяет, что Migrate без MIGRATE_DOMAIN_COLUMNS не трогает
+// таблицу старой схемы, а с ним переименовывает employes_id, создает представление со старым
+// именем и при повторном запуске ничего не меняет
+func TestMigrateRenamesDomainColumns(t *testing.T) {
+	db := testDB(t)
+	setTestEnv(t, nil)
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&model.Production{}); err != nil {
+		t.Fatal(err)
+	}
+	table := stmt.Schema.Table
+	if db.Migrator().HasTable(table) {
+		t.Skipf("таблица %s уже есть в тестовой БД", table)
+	}
+	dropTestTable(t, db, table)
+	if err := db.Exec("CREATE TABLE " + quoteIdentifier(table) + " (production_id SERIAL PRIMARY KEY, employes_id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO "+quoteIdentifier(table)+" (employes_id) VALUES (?)", 7).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	initializers.Migrate()
+	if !db.Migrator().HasColumn(&model.Production{}, "employes_id") {
+		t.Fatal("без MIGRATE_DOMAIN_COLUMNS колонка employes_id переименована")
+	}
+
+	setTestEnv(t, map[string]string{"MIGRATE_DOMAIN_COLUMNS": "true"})
+	for run := 1; run <= 2; run++ {
+		initializers.Migrate()
+
+		var employeeIDs []int
+		if err := db.Model(&model.Production{}).Pluck("employee_id", &employeeIDs).Error; err != nil {
+			t.Fatalf("запуск %d: чтение employee_id: %v", run, err)
+		}
+		if len(employeeIDs) != 1 || employeeIDs[0] != 7 {
+			t.Fatalf("запуск %d: %v, want [7]", run, employeeIDs)
+		}
+		if db.Migrator().HasColumn(&model.Production{}, "employes_id") {
+			t.Fatalf("запуск %d: колонка employes_id осталась", run)
+		}
+
+		var legacyIDs []int
+		if err := db.Table(initializers.LegacyViewName(table)).Pluck("employes_id", &legacyIDs).Error; err != nil {
+			t.Fatalf("запуск %d: чтение employes_id из представления: %v", run, err)
+		}
+		if len(legacyIDs) != 1 || legacyIDs[0] != 7 {
+			t.Fatalf("запуск %d: представление: %v, want [7]", run, legacyIDs)
+		}
+	}
+}
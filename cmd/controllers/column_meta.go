@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// parseDefaultLiteral разбирает значение по умолчанию из JSON:
+// null — удалить default (nil), строка — литерал в кавычках, число/bool — как есть
+func parseDefaultLiteral(raw json.RawMessage) (*string, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("некорректное значение default")
+	}
+
+	var literal string
+	switch v := value.(type) {
+	case string:
+		literal = quoteLiteral(v)
+	case float64, bool:
+		literal = fmt.Sprintf("%v", v)
+	default:
+		return nil, fmt.Errorf("default должен быть строкой, числом, bool или null")
+	}
+	return &literal, nil
+}
+
+// updateTableMeta применяет fn к метаданным таблицы и сохраняет их.
+// Таблицы, созданные не через API, метаданных не имеют — тогда ничего не делаем
+func updateTableMeta(tx *gorm.DB, tableName string, fn func(meta *model.TableMeta)) error {
+	var meta model.TableMeta
+	if err := tx.Where("name = ?", tableName).First(&meta).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	fn(&meta)
+	return tx.Save(&meta).Error
+}
+
+// updateColumnOptions меняет настройки одной колонки в метаданных
+func updateColumnOptions(meta *model.TableMeta, column string, fn func(opt *model.ColumnOptions)) {
+	options := map[string]model.ColumnOptions{}
+	if meta.ColumnOptions != "" {
+		json.Unmarshal([]byte(meta.ColumnOptions), &options)
+	}
+
+	opt := options[column]
+	fn(&opt)
+	options[column] = opt
+
+	optionsJSON, _ := json.Marshal(options)
+	meta.ColumnOptions = string(optionsJSON)
+}
+
+// renameColumnInMeta переименовывает колонку во всех полях метаданных
+func renameColumnInMeta(meta *model.TableMeta, oldName, newName string) {
+	var specs []string
+	json.Unmarshal([]byte(meta.Columns), &specs)
+	for i, spec := range specs {
+		if col, err := parseColumnSpec(spec); err == nil && col.Name == oldName {
+			specs[i] = newName + spec[len(oldName):]
+		}
+	}
+	columnsJSON, _ := json.Marshal(specs)
+	meta.Columns = string(columnsJSON)
+
+	options := map[string]model.ColumnOptions{}
+	if meta.ColumnOptions != "" {
+		json.Unmarshal([]byte(meta.ColumnOptions), &options)
+	}
+	if opt, ok := options[oldName]; ok {
+		delete(options, oldName)
+		options[newName] = opt
+		optionsJSON, _ := json.Marshal(options)
+		meta.ColumnOptions = string(optionsJSON)
+	}
+
+	if meta.ColumnOrder != "" {
+		var order []string
+		json.Unmarshal([]byte(meta.ColumnOrder), &order)
+		for i, col := range order {
+			if col == oldName {
+				order[i] = newName
+			}
+		}
+		orderJSON, _ := json.Marshal(order)
+		meta.ColumnOrder = string(orderJSON)
+	}
+}
+
+// setColumnTypeInMeta меняет тип колонки в описании "name:type[:modifiers]"
+func setColumnTypeInMeta(meta *model.TableMeta, column, colType string) {
+	var specs []string
+	json.Unmarshal([]byte(meta.Columns), &specs)
+	for i, spec := range specs {
+		col, err := parseColumnSpec(spec)
+		if err != nil || col.Name != column {
+			continue
+		}
+		rest := spec[len(col.Name)+1+len(col.Type):]
+		specs[i] = col.Name + ":" + colType + rest
+	}
+	columnsJSON, _ := json.Marshal(specs)
+	meta.Columns = string(columnsJSON)
+}
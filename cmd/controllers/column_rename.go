@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/initializers"
+	"server/model"
+)
+
+// columnRenameRequest — одно переименование в RenameColumnsBatch
+type columnRenameRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// RenameColumnsBatch переименовывает несколько колонок в одной транзакции:
+// либо применяются все переименования, либо ни одного. Итоговые имена проверяются
+// на совпадения заранее; обмен именами (a->b, b->a) выполняется через временные имена
+func RenameColumnsBatch(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var renames []columnRenameRequest
+	if err := c.ShouldBindJSON(&renames); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(renames) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указаны колонки для переименования"})
+		return
+	}
+
+	sources := make(map[string]bool, len(renames))
+	for i := range renames {
+		r := &renames[i]
+		r.From = initializers.NormalizeIdentifier(r.From)
+		r.To = initializers.NormalizeIdentifier(r.To)
+		if !isValidIdentifier(r.From) || !isValidIdentifier(r.To) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректное имя колонки", "index": i, "from": r.From, "to": r.To})
+			return
+		}
+		if err := checkIdentifierLength(r.To); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Слишком длинное имя колонки", "index": i, "details": err.Error()})
+			return
+		}
+		if sources[r.From] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Колонка указана несколько раз", "column": r.From})
+			return
+		}
+		sources[r.From] = true
+	}
+
+	table := quoteIdentifier(tableName)
+	var statements []string
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+
+		columnTypes, err := getColumnTypes(tx, tableName)
+		if err != nil {
+			return err
+		}
+		if len(columnTypes) == 0 {
+			return tableNotFound(tableName)
+		}
+		existing := make(map[string]bool, len(columnTypes))
+		for _, col := range columnTypes {
+			existing[col.ColumnName] = true
+		}
+
+		// Итоговый набор имен: колонки без переименования плюс новые имена
+		final := make(map[string]string, len(columnTypes))
+		for name := range existing {
+			if !sources[name] {
+				final[name] = name
+			}
+		}
+		swap := false
+		for _, r := range renames {
+			if !existing[r.From] {
+				apiErr := newAPIError(http.StatusNotFound, "Колонка не найдена", nil)
+				apiErr.Body["column"] = r.From
+				return apiErr
+			}
+			if other, dup := final[r.To]; dup {
+				apiErr := newAPIError(http.StatusConflict, "Итоговые имена колонок совпадают", nil)
+				apiErr.Body["name"] = r.To
+				apiErr.Body["columns"] = []string{other, r.From}
+				return apiErr
+			}
+			final[r.To] = r.From
+			swap = swap || (sources[r.To] && r.To != r.From)
+		}
+
+		// Если новое имя занято другой переименовываемой колонкой, сначала уводим все
+		// колонки во временные имена, иначе RENAME упадет на существующем имени
+		type step struct{ from, to string }
+		var steps, second []step
+		for i, r := range renames {
+			if r.From == r.To {
+				continue
+			}
+			if !swap {
+				steps = append(steps, step{r.From, r.To})
+				continue
+			}
+			tmp := truncatedIdentifier(fmt.Sprintf("__rename_%d_%s", i, r.From))
+			steps = append(steps, step{r.From, tmp})
+			second = append(second, step{tmp, r.To})
+		}
+		steps = append(steps, second...)
+
+		for _, s := range steps {
+			sql := fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, quoteIdentifier(s.from), quoteIdentifier(s.to))
+			if err := tx.Exec(sql).Error; err != nil {
+				apiErr := newAPIError(http.StatusBadRequest, "Ошибка выполнения SQL", err)
+				apiErr.Body["sql"] = sql
+				return apiErr
+			}
+			statements = append(statements, sql)
+		}
+
+		// Метаданные обновляем один раз, повторяя те же шаги
+		if err := updateTableMeta(tx, tableName, func(meta *model.TableMeta) {
+			for _, s := range steps {
+				renameColumnInMeta(meta, s.from, s.to)
+			}
+		}); err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка сохранения метаданных", err)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, withDebugSQL(c, gin.H{
+		"status":  "Колонки переименованы",
+		"renamed": renames,
+	}, strings.Join(statements, ";\n")))
+}
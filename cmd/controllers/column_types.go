@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// columnTypeInfo — допустимый тип колонки с подписью и примером значения для интерфейса
+type columnTypeInfo struct {
+	Type    string `json:"type"`
+	Label   string `json:"label"`
+	Example string `json:"example"`
+}
+
+// columnTypeCatalog — типы колонок, допустимые при создании и изменении таблиц, в порядке показа.
+// Параметризованных типов нет: VARCHAR допускается только как VARCHAR(255)
+var columnTypeCatalog = []columnTypeInfo{
+	{Type: "INTEGER", Label: "Целое число", Example: "42"},
+	{Type: "SERIAL", Label: "Автоинкремент", Example: "1"},
+	{Type: "VARCHAR(255)", Label: "Строка до 255 символов", Example: "Иванов"},
+	{Type: "TEXT", Label: "Текст", Example: "Произвольный текст"},
+	{Type: "BOOLEAN", Label: "Логическое значение", Example: "true"},
+	{Type: "DATE", Label: "Дата", Example: "2024-01-31"},
+	{Type: "TIMESTAMP", Label: "Дата и время", Example: "2024-01-31T12:00:00Z"},
+	{Type: "FLOAT", Label: "Дробное число", Example: "3.14"},
+	{Type: "JSON", Label: "JSON", Example: `{"key": "value"}`},
+	{Type: "UUID", Label: "UUID", Example: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+}
+
+// validColumnTypes — типы из columnTypeCatalog для проверки
+var validColumnTypes = func() map[string]bool {
+	types := make(map[string]bool, len(columnTypeCatalog))
+	for _, info := range columnTypeCatalog {
+		types[info.Type] = true
+	}
+	return types
+}()
+
+// ListColumnTypes возвращает допустимые типы колонок, чтобы интерфейс строил список из них
+func ListColumnTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"types": columnTypeCatalog})
+}
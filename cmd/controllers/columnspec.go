@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// columnSpec — разобранное описание колонки из CreateTable: name:type[:pk][:default=expr]
+type columnSpec struct {
+	Name       string
+	Type       string
+	PrimaryKey bool
+	Default    string
+}
+
+// defaultFuncPattern — значение по умолчанию в виде вызова функции без аргументов
+var defaultFuncPattern = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)\(\)$`)
+
+// defaultLiteralPattern — число, строка в одинарных кавычках, true/false или NULL
+var defaultLiteralPattern = regexp.MustCompile(`^(-?[0-9]+(\.[0-9]+)?|'([^']|'')*'|(?i:true|false|null))$`)
+
+// parseColumnSpec разбирает строку вида "name:type" с необязательными модификаторами после типа
+func parseColumnSpec(spec string) (columnSpec, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 {
+		return columnSpec{}, fmt.Errorf("ожидается name:type")
+	}
+
+	col := columnSpec{Name: parts[0], Type: parts[1]}
+	for i, modifier := range parts[2:] {
+		// Значение по умолчанию может содержать ':', поэтому забираем остаток строки целиком
+		if strings.HasPrefix(strings.ToLower(modifier), "default=") {
+			col.Default = strings.Join(parts[2+i:], ":")[len("default="):]
+			if !defaultFuncPattern.MatchString(col.Default) && !defaultLiteralPattern.MatchString(col.Default) {
+				return columnSpec{}, fmt.Errorf("недопустимое значение по умолчанию '%s'", col.Default)
+			}
+			break
+		}
+
+		switch strings.ToLower(modifier) {
+		case "pk":
+			col.PrimaryKey = true
+		default:
+			return columnSpec{}, fmt.Errorf("неизвестный модификатор '%s'", modifier)
+		}
+	}
+	return col, nil
+}
+
+// defaultFunction возвращает имя функции из значения по умолчанию, если это вызов функции
+func (col columnSpec) defaultFunction() string {
+	if m := defaultFuncPattern.FindStringSubmatch(col.Default); m != nil {
+		return strings.ToLower(m[1])
+	}
+	return ""
+}
+
+// definition формирует определение колонки для CREATE TABLE
+func (col columnSpec) definition() string {
+	def := fmt.Sprintf("%s %s", quoteIdentifier(col.Name), col.Type)
+	if col.PrimaryKey {
+		def += " PRIMARY KEY"
+	}
+	if col.Default != "" {
+		def += " DEFAULT " + col.Default
+	}
+	return def
+}
+
+// functionExtensions — расширения, предоставляющие функции генерации UUID
+var functionExtensions = map[string]string{
+	"gen_random_uuid":    "pgcrypto (или PostgreSQL 13+)",
+	"uuid_generate_v4":   "uuid-ossp",
+	"uuid_generate_v1":   "uuid-ossp",
+	"uuid_generate_v1mc": "uuid-ossp",
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// validateCheckExpression отклоняет выражения CHECK, которые могут выйти за рамки
+// одного условия: разделители, комментарии, подзапросы и несбалансированные скобки
+func validateCheckExpression(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return fmt.Errorf("пустое выражение")
+	}
+	if strings.Contains(expr, ";") || strings.Contains(expr, "--") || strings.Contains(expr, "/*") {
+		return fmt.Errorf("выражение не должно содержать ';' и комментарии")
+	}
+	if strings.Contains(strings.ToUpper(expr), "SELECT") {
+		return fmt.Errorf("подзапросы в CHECK не поддерживаются")
+	}
+
+	depth := 0
+	inQuote := false
+	for _, r := range expr {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case r == '(' && !inQuote:
+			depth++
+		case r == ')' && !inQuote:
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("несбалансированные скобки")
+			}
+		}
+	}
+	if depth != 0 || inQuote {
+		return fmt.Errorf("несбалансированные скобки или кавычки")
+	}
+	return nil
+}
+
+// AddConstraint создает именованное ограничение UNIQUE по нескольким колонкам
+// или CHECK с выражением. Имя по умолчанию: <таблица>_<колонки>_key / <таблица>_check
+func AddConstraint(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var req struct {
+		Type       string   `json:"type" binding:"required"`
+		Name       string   `json:"name"`
+		Columns    []string `json:"columns"`
+		Expression string   `json:"expression"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(columnTypes) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+
+	var definition string
+	switch req.Type {
+	case "unique":
+		if len(req.Columns) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Для unique нужно указать columns"})
+			return
+		}
+
+		existing := make(map[string]bool, len(columnTypes))
+		for _, col := range columnTypes {
+			existing[col.ColumnName] = true
+		}
+
+		quoted := make([]string, len(req.Columns))
+		for i, col := range req.Columns {
+			if !existing[col] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Колонка не найдена", "column": col})
+				return
+			}
+			quoted[i] = quoteIdentifier(col)
+		}
+
+		definition = fmt.Sprintf("UNIQUE (%s)", strings.Join(quoted, ", "))
+		if req.Name == "" {
+			req.Name = truncatedIdentifier(fmt.Sprintf("%s_%s_key", tableName, strings.Join(req.Columns, "_")))
+		}
+	case "check":
+		if err := validateCheckExpression(req.Expression); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректное выражение CHECK", "details": err.Error()})
+			return
+		}
+
+		definition = fmt.Sprintf("CHECK (%s)", req.Expression)
+		if req.Name == "" {
+			req.Name = truncatedIdentifier(tableName + "_check")
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Недопустимый тип ограничения",
+			"allowed": []string{"unique", "check"},
+		})
+		return
+	}
+
+	if !isValidIdentifier(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректное имя ограничения", "name": req.Name})
+		return
+	}
+	if err := checkIdentifierLength(req.Name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Слишком длинное имя ограничения", "details": err.Error()})
+		return
+	}
+
+	sql := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s",
+		quoteIdentifier(tableName), quoteIdentifier(req.Name), definition)
+
+	var created ddlConstraint
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+		if err := tx.Exec(sql).Error; err != nil {
+			apiErr := newAPIError(http.StatusBadRequest, "Ошибка создания ограничения", err)
+			apiErr.Body["sql"] = sql
+			return apiErr
+		}
+
+		return tx.Raw(`
+			SELECT conname, pg_get_constraintdef(oid) AS definition
+			FROM pg_constraint
+			WHERE conrelid = ?::regclass AND conname = ?
+		`, quoteIdentifier(tableName), req.Name).Scan(&created).Error
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, withDebugSQL(c, gin.H{
+		"status":     "Ограничение создано",
+		"name":       created.Name,
+		"definition": created.Definition,
+	}, sql))
+}
+
+// DropConstraint удаляет ограничение :constraintName таблицы
+func DropConstraint(c *gin.Context) {
+	tableName := c.Param("name")
+	constraintName := c.Param("constraintName")
+
+	if !requireTable(c, tableName) {
+		return
+	}
+
+	var constraintExists bool
+	if err := getDB(c).Raw(`
+		SELECT EXISTS (
+			SELECT FROM pg_constraint
+			WHERE conrelid = ?::regclass AND conname = ?
+		)`, quoteIdentifier(tableName), constraintName).Scan(&constraintExists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !constraintExists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ограничение не найдено"})
+		return
+	}
+
+	sql := fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", quoteIdentifier(tableName), quoteIdentifier(constraintName))
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+		if err := tx.Exec(sql).Error; err != nil {
+			return newAPIError(http.StatusBadRequest, "Ошибка удаления ограничения", err)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, withDebugSQL(c, gin.H{"status": "Ограничение удалено"}, sql))
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+	"server/initializers"
+)
+
+// errCopyUnsupported — драйвер подключения не поддерживает протокол COPY
+var errCopyUnsupported = errors.New("COPY не поддерживается драйвером")
+
+// copyTx — транзакция запроса, открытая на выделенном подключении pgx: в ней, кроме обычных
+// запросов GORM, можно выполнить COPY FROM STDIN. COPY передает CSV одним потоком вместо
+// INSERT на каждую строку; замер — BenchmarkRestoreTable
+type copyTx struct {
+	*gorm.DB
+	conn *sql.Conn
+}
+
+// beginCopyTx начинает транзакцию, как beginTx (со statement_timeout запроса), но на
+// выделенном подключении. Если драйвер не pgx, возвращает errCopyUnsupported — тогда
+// используется обычная транзакция и построчная вставка. Подключение освобождает close
+func beginCopyTx(c *gin.Context) (*copyTx, error) {
+	db := getDB(c)
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Raw(func(driverConn interface{}) error {
+		if _, ok := driverConn.(*stdlib.Conn); !ok {
+			return errCopyUnsupported
+		}
+		return nil
+	}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Отдельная копия сессии запроса, чтобы не заменить подключение в ней самой
+	session := db.Session(&gorm.Session{Context: db.Statement.Context})
+	session.Statement.ConnPool = conn
+	tx := initializers.BeginTx(session)
+	if tx.Error != nil {
+		conn.Close()
+		return nil, tx.Error
+	}
+	return &copyTx{DB: tx, conn: conn}, nil
+}
+
+// copyFrom выполняет COPY ... FROM STDIN в транзакции и возвращает число загруженных строк
+func (t *copyTx) copyFrom(copySQL string, data io.Reader) (int64, error) {
+	var rowCount int64
+	err := t.conn.Raw(func(driverConn interface{}) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn().PgConn()
+		tag, err := pgConn.CopyFrom(t.Statement.Context, data, copySQL)
+		rowCount = tag.RowsAffected()
+		return err
+	})
+	return rowCount, err
+}
+
+// close возвращает подключение в пул; транзакция к этому моменту зафиксирована или откачена
+func (t *copyTx) close() {
+	t.conn.Close()
+}
+
+// copyCSVStatement строит COPY FROM STDIN для CSV в диалекте dialect. ok=false, если диалект
+// COPY не поддерживает (см. csvDialect.copyOptions).
+// NULL — это значение NULL в кавычках или без, как и при построчной вставке (FORCE_NULL)
+func copyCSVStatement(tableName string, columns []string, header bool, dialect csvDialect) (string, bool) {
+	options, ok := dialect.copyOptions()
+	if !ok {
+		return "", false
+	}
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(col)
+	}
+	list := strings.Join(quoted, ", ")
+	return fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv, HEADER %t, NULL 'NULL', FORCE_NULL (%s), %s)",
+		quoteIdentifier(tableName), list, header, list, options), true
+}
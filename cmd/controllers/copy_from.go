@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/initializers"
+)
+
+// columnCast — совместимость колонки источника с колонкой назначения
+type columnCast struct {
+	SourceType string `gorm:"column:source_type" json:"sourceType"`
+	TargetType string `gorm:"column:target_type" json:"targetType"`
+	Compatible bool   `gorm:"column:compatible" json:"compatible"`
+}
+
+// checkColumnCast проверяет, что значение колонки source.sourceColumn можно записать
+// в target.targetColumn: тот же тип, неявное приведение или приведение при присваивании,
+// а также любая колонка в строковую (через текстовое представление)
+func checkColumnCast(db *gorm.DB, source, sourceColumn, target, targetColumn string) (columnCast, error) {
+	var cast columnCast
+	err := db.Raw(`
+		SELECT format_type(s.atttypid, s.atttypmod) AS source_type,
+			format_type(t.atttypid, t.atttypmod) AS target_type,
+			(s.atttypid = t.atttypid
+				OR EXISTS (
+					SELECT FROM pg_cast
+					WHERE castsource = s.atttypid AND casttarget = t.atttypid AND castcontext IN ('a', 'i')
+				)
+				OR (SELECT typcategory FROM pg_type WHERE oid = t.atttypid) = 'S') AS compatible
+		FROM pg_attribute s, pg_attribute t
+		WHERE s.attrelid = ?::regclass AND s.attname = ?
+			AND t.attrelid = ?::regclass AND t.attname = ?
+	`, quoteIdentifier(source), sourceColumn, quoteIdentifier(target), targetColumn).Scan(&cast).Error
+	return cast, err
+}
+
+// CopyFromTable копирует строки из другой таблицы одним INSERT ... SELECT:
+// {"source": "old_items", "mapping": {"srcCol": "destCol"}, "filter": ["col:op:value"]}.
+// Фильтры — как ?filter= у GetTableData, по колонкам источника. Возвращает число вставленных строк
+func CopyFromTable(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var req struct {
+		Source  string            `json:"source" binding:"required"`
+		Mapping map[string]string `json:"mapping" binding:"required"`
+		Filter  []string          `json:"filter"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Mapping) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указано ни одной колонки в mapping"})
+		return
+	}
+
+	req.Source = initializers.NormalizeIdentifier(req.Source)
+	if !initializers.TableAllowed(req.Source) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Доступ к таблице запрещен", "table": req.Source})
+		return
+	}
+
+	targetTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(targetTypes) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+	sourceTypes, err := getColumnTypes(getDB(c), req.Source)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(sourceTypes) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Таблица-источник не найдена", "table": req.Source})
+		return
+	}
+
+	targetColumns := make(map[string]bool, len(targetTypes))
+	for _, col := range targetTypes {
+		targetColumns[col.ColumnName] = true
+	}
+	sourceColumns := make(map[string]string, len(sourceTypes))
+	for _, col := range sourceTypes {
+		sourceColumns[col.ColumnName] = col.DataType
+	}
+
+	// Порядок колонок в SQL — по имени колонки источника, чтобы запрос был воспроизводимым
+	sourceNames := make([]string, 0, len(req.Mapping))
+	for src := range req.Mapping {
+		sourceNames = append(sourceNames, src)
+	}
+	sort.Strings(sourceNames)
+
+	used := make(map[string]string, len(req.Mapping))
+	insertColumns := make([]string, 0, len(sourceNames))
+	selectColumns := make([]string, 0, len(sourceNames))
+	for _, src := range sourceNames {
+		dest := initializers.NormalizeIdentifier(req.Mapping[src])
+		src := initializers.NormalizeIdentifier(src)
+		if _, ok := sourceColumns[src]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Колонка источника не найдена", "column": src})
+			return
+		}
+		if !targetColumns[dest] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Колонка назначения не найдена", "column": dest})
+			return
+		}
+		if other, dup := used[dest]; dup {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "В одну колонку назначения отображено несколько колонок",
+				"column":  dest,
+				"sources": []string{other, src},
+			})
+			return
+		}
+		used[dest] = src
+
+		cast, err := checkColumnCast(getDB(c), req.Source, src, tableName, dest)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !cast.Compatible {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":      "Несовместимые типы колонок",
+				"source":     src,
+				"target":     dest,
+				"sourceType": cast.SourceType,
+				"targetType": cast.TargetType,
+			})
+			return
+		}
+
+		insertColumns = append(insertColumns, quoteIdentifier(dest))
+		selectColumns = append(selectColumns, quoteIdentifier(src))
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, raw := range req.Filter {
+		filter, err := parseFilter(raw, sourceColumns)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		conditions = append(conditions, filter.Expr)
+		args = append(args, filter.Args...)
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+		quoteIdentifier(tableName), strings.Join(insertColumns, ", "),
+		strings.Join(selectColumns, ", "), quoteIdentifier(req.Source))
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var inserted int64
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		result := tx.Exec(sql, args...)
+		if result.Error != nil {
+			apiErr := dbWriteError(result.Error)
+			apiErr.Body["sql"] = sql
+			return apiErr
+		}
+		inserted = result.RowsAffected
+
+		logTableChange(tx, c, tableName, "copy", "", map[string]interface{}{
+			"source":   req.Source,
+			"inserted": inserted,
+		})
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, withDebugSQL(c, gin.H{
+		"status":   "Строки скопированы",
+		"source":   req.Source,
+		"inserted": inserted,
+	}, sql))
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csvDialect — разделитель и символ кавычек CSV. Нулевые значения означают ',' и '"'
+type csvDialect struct {
+	Delimiter  rune
+	Quote      rune
+	LazyQuotes bool // терпеть некорректные кавычки при чтении
+}
+
+// parseCSVDialect читает и проверяет ?delimiter=, ?quote= и ?lazyQuotes=true
+func parseCSVDialect(c *gin.Context) (csvDialect, error) {
+	var d csvDialect
+	var err error
+
+	if d.Delimiter, err = singleRune("delimiter", c.Query("delimiter")); err != nil {
+		return csvDialect{}, err
+	}
+	if d.Quote, err = singleRune("quote", c.Query("quote")); err != nil {
+		return csvDialect{}, err
+	}
+	d.LazyQuotes = c.Query("lazyQuotes") == "true"
+
+	// Кавычки подменяются побайтно, поэтому допускаются только ASCII-символы
+	if d.quote() >= utf8.RuneSelf || d.quote() == '\r' || d.quote() == '\n' {
+		return csvDialect{}, fmt.Errorf("некорректный quote '%c': допускается один ASCII-символ", d.quote())
+	}
+	switch d.delimiter() {
+	case '"', '\r', '\n', utf8.RuneError:
+		return csvDialect{}, fmt.Errorf("некорректный delimiter '%c'", d.delimiter())
+	}
+	if d.delimiter() == d.quote() {
+		return csvDialect{}, fmt.Errorf("delimiter и quote должны различаться")
+	}
+
+	return d, nil
+}
+
+// singleRune возвращает единственный символ значения параметра или 0, если параметр не задан
+func singleRune(param, value string) (rune, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if value == `\t` {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(value)
+	if size != len(value) || r == utf8.RuneError {
+		return 0, fmt.Errorf("%s должен быть одним символом, получено '%s'", param, value)
+	}
+	return r, nil
+}
+
+func (d csvDialect) delimiter() rune {
+	if d.Delimiter == 0 {
+		return ','
+	}
+	return d.Delimiter
+}
+
+func (d csvDialect) quote() rune {
+	if d.Quote == 0 {
+		return '"'
+	}
+	return d.Quote
+}
+
+// swapQuotes меняет местами '"' и символ кавычек диалекта. encoding/csv понимает только '"',
+// поэтому данные читаются и пишутся с подменой, а значения полей подменяются обратно
+func (d csvDialect) swapQuotes(data []byte) []byte {
+	q := byte(d.quote())
+	if q == '"' {
+		return data
+	}
+	swapped := make([]byte, len(data))
+	for i, b := range data {
+		switch b {
+		case q:
+			swapped[i] = '"'
+		case '"':
+			swapped[i] = q
+		default:
+			swapped[i] = b
+		}
+	}
+	return swapped
+}
+
+func (d csvDialect) swapRecord(record []string) []string {
+	if d.quote() == '"' {
+		return record
+	}
+	for i, v := range record {
+		record[i] = string(d.swapQuotes([]byte(v)))
+	}
+	return record
+}
+
+// csvReader — csv.Reader с учетом символа кавычек диалекта
+type csvReader struct {
+	*csv.Reader
+	dialect csvDialect
+}
+
+// newReader создает читатель CSV для данных в этом диалекте
+func (d csvDialect) newReader(data []byte) *csvReader {
+	reader := csv.NewReader(bytes.NewReader(d.swapQuotes(data)))
+	reader.Comma = d.delimiter()
+	reader.LazyQuotes = d.LazyQuotes
+	return &csvReader{Reader: reader, dialect: d}
+}
+
+func (r *csvReader) Read() ([]string, error) {
+	record, err := r.Reader.Read()
+	return r.dialect.swapRecord(record), err
+}
+
+func (r *csvReader) ReadAll() ([][]string, error) {
+	records, err := r.Reader.ReadAll()
+	for _, record := range records {
+		r.dialect.swapRecord(record)
+	}
+	return records, err
+}
+
+// csvWriter — csv.Writer с учетом символа кавычек диалекта
+type csvWriter struct {
+	*csv.Writer
+	dialect csvDialect
+}
+
+// newWriter создает писатель CSV в этом диалекте
+func (d csvDialect) newWriter(w io.Writer) *csvWriter {
+	if d.quote() != '"' {
+		w = &swapWriter{w: w, dialect: d}
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = d.delimiter()
+	return &csvWriter{Writer: writer, dialect: d}
+}
+
+func (w *csvWriter) Write(record []string) error {
+	swapped := make([]string, len(record))
+	copy(swapped, record)
+	return w.Writer.Write(w.dialect.swapRecord(swapped))
+}
+
+// swapWriter подменяет кавычки в выводе csv.Writer
+type swapWriter struct {
+	w       io.Writer
+	dialect csvDialect
+}
+
+func (s *swapWriter) Write(p []byte) (int, error) {
+	if _, err := s.w.Write(s.dialect.swapQuotes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// copyOptions возвращает параметры COPY для диалекта. ok=false, если COPY его не поддерживает
+// (многобайтовый разделитель или нестрогий разбор кавычек)
+func (d csvDialect) copyOptions() (string, bool) {
+	if d.LazyQuotes || d.delimiter() >= utf8.RuneSelf {
+		return "", false
+	}
+	return fmt.Sprintf("DELIMITER %s, QUOTE %s", quoteLiteral(string(d.delimiter())), quoteLiteral(string(d.quote()))), true
+}
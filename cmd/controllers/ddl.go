@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ddlColumn — описание колонки для восстановления DDL
+type ddlColumn struct {
+	ColumnName             string  `gorm:"column:column_name"`
+	DataType               string  `gorm:"column:data_type"`
+	UdtName                string  `gorm:"column:udt_name"`
+	CharacterMaximumLength *int    `gorm:"column:character_maximum_length"`
+	IsNullable             string  `gorm:"column:is_nullable"`
+	ColumnDefault          *string `gorm:"column:column_default"`
+}
+
+// sqlType возвращает тип колонки в виде, пригодном для CREATE TABLE
+func (col ddlColumn) sqlType() string {
+	switch col.DataType {
+	case "USER-DEFINED":
+		return col.UdtName
+	case "ARRAY":
+		return strings.TrimPrefix(col.UdtName, "_") + "[]"
+	}
+	if col.CharacterMaximumLength != nil {
+		return fmt.Sprintf("%s(%d)", col.DataType, *col.CharacterMaximumLength)
+	}
+	return col.DataType
+}
+
+// nextvalPattern выделяет имя последовательности из значения по умолчанию SERIAL-колонки
+var nextvalPattern = regexp.MustCompile(`nextval\('([^']+)'::regclass\)`)
+
+// ddlConstraint — ограничение таблицы (PK, UNIQUE, CHECK, FK)
+type ddlConstraint struct {
+	Name       string `gorm:"column:conname"`
+	Definition string `gorm:"column:definition"`
+}
+
+// buildTableDDL восстанавливает CREATE TABLE и индексы таблицы по системным каталогам.
+// Возвращает DDL и список нужных расширений; для несуществующей таблицы DDL пустой
+func buildTableDDL(db *gorm.DB, tableName string) (string, []string, error) {
+	var columns []ddlColumn
+	if err := db.Raw(`
+		SELECT column_name, data_type, udt_name, character_maximum_length, is_nullable, column_default
+		FROM information_schema.columns
+		WHERE table_name = ?
+		ORDER BY ordinal_position
+	`, tableName).Scan(&columns).Error; err != nil {
+		return "", nil, err
+	}
+	if len(columns) == 0 {
+		return "", nil, nil
+	}
+
+	var constraints []ddlConstraint
+	if err := db.Raw(`
+		SELECT conname, pg_get_constraintdef(oid) AS definition
+		FROM pg_constraint
+		WHERE conrelid = ?::regclass
+		ORDER BY contype, conname
+	`, quoteIdentifier(tableName)).Scan(&constraints).Error; err != nil {
+		return "", nil, err
+	}
+
+	// Индексы, не созданные ограничениями (PK/UNIQUE создают свои индексы сами)
+	var indexes []string
+	if err := db.Raw(`
+		SELECT indexdef
+		FROM pg_indexes
+		WHERE tablename = ?
+		AND indexname NOT IN (SELECT conname FROM pg_constraint WHERE conrelid = ?::regclass)
+		ORDER BY indexname
+	`, tableName, quoteIdentifier(tableName)).Scan(&indexes).Error; err != nil {
+		return "", nil, err
+	}
+
+	var lines, sequences, owned []string
+	extensions := map[string]bool{}
+	for _, col := range columns {
+		line := fmt.Sprintf("%s %s", quoteIdentifier(col.ColumnName), col.sqlType())
+		if col.IsNullable == "NO" {
+			line += " NOT NULL"
+		}
+		if col.ColumnDefault != nil {
+			// Последовательность SERIAL-колонки создается до таблицы и привязывается к колонке после
+			if m := nextvalPattern.FindStringSubmatch(*col.ColumnDefault); m != nil {
+				sequences = append(sequences, fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s;\n", m[1]))
+				owned = append(owned, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s;\n",
+					m[1], quoteIdentifier(tableName), quoteIdentifier(col.ColumnName)))
+			}
+			line += " DEFAULT " + *col.ColumnDefault
+			for fn, ext := range functionExtensions {
+				if strings.Contains(*col.ColumnDefault, fn+"(") {
+					extensions[ext] = true
+				}
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	for _, con := range constraints {
+		lines = append(lines, fmt.Sprintf("CONSTRAINT %s %s", quoteIdentifier(con.Name), con.Definition))
+	}
+
+	ddl := strings.Join(sequences, "")
+	ddl += fmt.Sprintf("CREATE TABLE %s (\n  %s\n);\n", quoteIdentifier(tableName), strings.Join(lines, ",\n  "))
+	ddl += strings.Join(owned, "")
+	for _, idx := range indexes {
+		ddl += idx + ";\n"
+	}
+
+	return ddl, getKeys(extensions), nil
+}
+
+// GetTableDDL возвращает CREATE TABLE для существующей таблицы в виде текста
+func GetTableDDL(c *gin.Context) {
+	tableName := c.Param("name")
+
+	ddl, extensions, err := buildTableDDL(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if ddl == "" {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+
+	var header string
+	for _, ext := range extensions {
+		header += fmt.Sprintf("-- Требуется расширение: %s\n", ext)
+	}
+
+	c.String(http.StatusOK, header+ddl)
+}
@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+// withDebugSQL добавляет в ответ выполненный DDL-запрос, если передан ?debugSql=true
+// и это разрешено настройкой DEBUG_SQL_RESPONSES. Без настройки параметр игнорируется
+func withDebugSQL(c *gin.Context, response gin.H, sql string) gin.H {
+	if c.Query("debugSql") == "true" && initializers.GetConfig().DebugSQLResponses {
+		response["sql"] = sql
+	}
+	return response
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"server/initializers"
+	"server/model"
+)
+
+// Типизированные списки доменных моделей: GET /api/employees и т.д.
+var (
+	ListEmployees   = listModel[model.Employee]
+	ListProductions = listModel[model.Production]
+	ListDeliveries  = listModel[model.Delivery]
+	ListTransports  = listModel[model.Transport]
+	ListSalesPoints = listModel[model.SalesPoint]
+	ListProducts    = listModel[model.Product]
+	ListMaterials   = listModel[model.Material]
+)
+
+// modelFieldParam возвращает имя поля модели в JSON — оно же имя параметра запроса
+func modelFieldParam(field *schema.Field) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.DBName
+	}
+	return name
+}
+
+// fieldBound — параметр запроса, фильтрующий поле модели оператором op
+type fieldBound struct {
+	param string
+	op    string
+}
+
+// listModel отдает страницу строк таблицы модели T в виде структур T.
+// Фильтры: ?<поле>=значение (равенство), ?min<Поле>=/?max<Поле>= для чисел и дат,
+// а также ?filter=column:op:value по именам колонок БД из модели.
+// Сортировка — ?sort=<поле>&order=asc|desc, по умолчанию по первичному ключу
+func listModel[T any](c *gin.Context) {
+	stmt := &gorm.Statement{DB: getDB(c)}
+	if err := stmt.Parse(new(T)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tableName := stmt.Schema.Table
+
+	if !initializers.TableAllowed(tableName) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Доступ к таблице запрещен", "table": tableName})
+		return
+	}
+
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(columnTypes) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		if _, ok := stmt.Schema.FieldsByDBName[col.ColumnName]; ok {
+			types[col.ColumnName] = col.DataType
+		}
+	}
+
+	// Поля модели по имени параметра: и JSON-имя, и имя колонки
+	fields := make(map[string]*schema.Field, len(types)*2)
+	for column := range types {
+		field := stmt.Schema.FieldsByDBName[column]
+		fields[modelFieldParam(field)] = field
+		fields[column] = field
+	}
+
+	db := getDB(c).Model(new(T))
+	for _, raw := range c.QueryArray("filter") {
+		filter, err := parseFilter(raw, types)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		db = db.Where(filter.Expr, filter.Args...)
+	}
+
+	// Фильтры по полям: значения приводятся к типу колонки и передаются параметрами
+	for column, dataType := range types {
+		param := modelFieldParam(stmt.Schema.FieldsByDBName[column])
+		bounds := []fieldBound{{param, "="}}
+		if isIntegerType(dataType) || isFloatType(dataType) || isTimeType(dataType) {
+			suffix := strings.ToUpper(param[:1]) + param[1:]
+			bounds = append(bounds, fieldBound{"min" + suffix, ">="}, fieldBound{"max" + suffix, "<="})
+		}
+
+		for _, b := range bounds {
+			raw, ok := c.GetQuery(b.param)
+			if !ok {
+				continue
+			}
+			value, err := coerceInput(raw, dataType)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": b.param})
+				return
+			}
+			db = db.Where(fmt.Sprintf("%s %s ?", quoteIdentifier(column), b.op), value)
+		}
+	}
+
+	order := strings.ToLower(c.DefaultQuery("order", "asc"))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order должен быть asc или desc", "received": order})
+		return
+	}
+	params := make([]string, 0, len(types))
+	for column := range types {
+		params = append(params, modelFieldParam(stmt.Schema.FieldsByDBName[column]))
+	}
+	sort.Strings(params)
+
+	sortField := stmt.Schema.PrioritizedPrimaryField
+	if name := c.Query("sort"); name != "" {
+		field, ok := fields[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Нельзя сортировать по этому полю", "sort": name, "fields": params})
+			return
+		}
+		sortField = field
+	}
+
+	// Проекция ?fields=a,b,c по полям модели; первичный ключ возвращается всегда
+	var projected []string
+	if raw, ok := c.GetQuery("fields"); ok {
+		selected, apiErr := parseFieldsParam(raw, func(name string) (string, bool) {
+			field, ok := fields[name]
+			if !ok {
+				return "", false
+			}
+			return field.DBName, true
+		})
+		if apiErr != nil {
+			apiErr.Body["fields"] = params
+			respondError(c, apiErr)
+			return
+		}
+
+		columns := make([]string, 0, len(types))
+		for _, column := range stmt.Schema.DBNames {
+			if _, ok := types[column]; ok {
+				columns = append(columns, column)
+			}
+		}
+		var required []string
+		for _, pk := range stmt.Schema.PrimaryFields {
+			required = append(required, pk.DBName)
+		}
+		projected = projectColumns(columns, selected, required)
+	}
+
+	page := parsePagination(c)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	db = db.Offset(page.Offset()).Limit(page.PageSize)
+	if projected != nil {
+		db = db.Select(selectClause(projected))
+	}
+	if sortField != nil {
+		db = db.Order(quoteIdentifier(sortField.DBName) + " " + order)
+		// Второй ключ — первичный, чтобы страницы не пересекались при равных значениях
+		if pk := stmt.Schema.PrioritizedPrimaryField; pk != nil && pk != sortField {
+			db = db.Order(quoteIdentifier(pk.DBName))
+		}
+	}
+
+	var items []T
+	if err := db.Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logTableRead(c, tableName, "read", len(items))
+
+	if projected != nil {
+		c.JSON(http.StatusOK, paginatedResponse(projectItems(c, stmt.Schema, items, projected), page, total))
+		return
+	}
+	c.JSON(http.StatusOK, paginatedResponse(items, page, total))
+}
+
+// projectItems превращает структуры в объекты только с полями columns, ключи — JSON-имена полей
+func projectItems[T any](ctx context.Context, s *schema.Schema, items []T, columns []string) []map[string]interface{} {
+	rows := make([]map[string]interface{}, len(items))
+	for i := range items {
+		value := reflect.ValueOf(&items[i]).Elem()
+		row := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			field := s.FieldsByDBName[column]
+			row[modelFieldParam(field)], _ = field.ValueOf(ctx, value)
+		}
+		rows[i] = row
+	}
+	return rows
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveDownload отдает файл с поддержкой Range-запросов (Accept-Ranges: bytes, ответ 206),
+// чтобы клиент мог докачать прерванную загрузку. ETag считается по содержимому: файл
+// формируется заново при каждом запросе, и If-Range отдаст часть только от тех же данных
+func serveDownload(c *gin.Context, path, filename string) {
+	f, err := os.Open(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка открытия файла"})
+		return
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения файла"})
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения файла"})
+		return
+	}
+
+	c.Header("ETag", `"`+hex.EncodeToString(hash.Sum(nil))+`"`)
+	c.Header("Content-Disposition", attachmentDisposition(filename))
+	http.ServeContent(c.Writer, c.Request, filename, time.Time{}, f)
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// ExportTableByQuery выгружает в CSV только строки таблицы :table, первичные ключи которых
+// вернул сохраненный запрос: {"queryId": 1, "args": [...]}. Запрос должен быть одиночным SELECT
+// с колонкой, названной как первичный ключ таблицы; выполняется в транзакции только для чтения.
+// Параметры формата и сортировки — как у ExportTable
+func ExportTableByQuery(c *gin.Context) {
+	table := c.Param("table")
+
+	var req struct {
+		QueryID int           `json:"queryId" binding:"required"`
+		Args    []interface{} `json:"args"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !requireTable(c, table) {
+		return
+	}
+
+	var query model.SavedQuery
+	if err := getDB(c).First(&query, req.QueryID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Запрос не найден", "id": req.QueryID})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if !isSelectQuery(query.Query) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Для выгрузки нужен одиночный SELECT",
+			"kind":  statementKind(query.Query),
+		})
+		return
+	}
+
+	format, err := parseExportFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	columnTypes, err := getColumnTypes(getDB(c), table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	columns := make([]string, len(columnTypes))
+	types := make(map[string]string, len(columnTypes))
+	for i, col := range columnTypes {
+		columns[i] = col.ColumnName
+		types[col.ColumnName] = col.DataType
+	}
+	if err := format.checkColumns(columns); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "columns": columns})
+		return
+	}
+
+	sorting, sorted, err := resolveTableSort(c, getDB(c), table, types)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if sorted {
+		format.OrderBy = sorting.orderClause()
+	}
+
+	pkColumn, err := getPrimaryKeyColumn(getDB(c), table)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "У таблицы нет первичного ключа", "details": err.Error()})
+		return
+	}
+
+	tx, err := beginReadOnlyTx(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	// Проверяем, что запрос возвращает колонку первичного ключа, не выбирая строк
+	queryText := trimStatement(query.Query)
+	rows, err := tx.Raw(fmt.Sprintf("SELECT * FROM (%s) AS q LIMIT 0", queryText), req.Args...).Rows()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка выполнения запроса", "details": err.Error()})
+		return
+	}
+	resultColumns, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	hasKey := false
+	for _, name := range resultColumns {
+		if name == pkColumn {
+			hasKey = true
+			break
+		}
+	}
+	if !hasKey {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Запрос не возвращает первичный ключ таблицы",
+			"pk":      pkColumn,
+			"columns": resultColumns,
+		})
+		return
+	}
+
+	format.Where = fmt.Sprintf("%s IN (SELECT q.%s FROM (%s) AS q)",
+		quoteIdentifier(pkColumn), quoteIdentifier(pkColumn), queryText)
+	format.WhereArgs = req.Args
+
+	query.LastUsed = time.Now()
+	query.UseCount += 1
+	getDB(c).Save(&query)
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", attachmentDisposition(table+".csv"))
+
+	rowCount, err := exportTableToWriter(tx, table, c.Writer, format)
+	if isQueryCanceled(err) {
+		respondError(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logTableRead(c, table, "export", rowCount)
+}
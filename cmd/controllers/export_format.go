@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+// numFormatPattern — допустимые форматы чисел: %d или %f/%e/%g с необязательными флагами и точностью
+var numFormatPattern = regexp.MustCompile(`^%[-+ 0]?[0-9]{0,2}(\.[0-9]{1,2})?[dfeEgG]$`)
+
+// dateLayoutTokens — элементы шаблона Go, хотя бы один из которых должен быть в dateFormat
+var dateLayoutTokens = []string{"2006", "06", "01", "Jan", "02", "_2", "15", "03", "04", "05"}
+
+// Форматы логических значений в выгрузке (?boolFormat=)
+const (
+	boolFormatText    = "text"
+	boolFormatNumeric = "numeric"
+)
+
+// exportFormat — форматирование значений при выгрузке в CSV
+type exportFormat struct {
+	DateFormat string            // шаблон time.Format, по умолчанию RFC3339
+	NumFormat  string            // шаблон fmt для чисел, по умолчанию без экспоненты
+	BoolFormat string            // boolFormatText (true/false, по умолчанию) или boolFormatNumeric (1/0)
+	Dialect    csvDialect        // разделитель и кавычки
+	HeaderMap  map[string]string // колонка -> заголовок в CSV
+	OrderBy    string            // выражение ORDER BY для выгрузки таблицы, пустое — без сортировки
+	Columns    []string          // выгружаемые колонки в нужном порядке, пустой — все
+	MaxRows    int               // предел строк, 0 — без предела (бэкапы)
+	Where      string            // условие WHERE для выгрузки таблицы, пустое — все строки
+	WhereArgs  []interface{}     // параметры для Where
+}
+
+// parseExportFormat читает и проверяет ?dateFormat=, ?numFormat=, ?boolFormat=, ?delimiter=, ?quote=, ?headerMap=,
+// ?columns= и ?maxRows=. Колонки проверяются отдельно (checkColumns), когда известен их список
+func parseExportFormat(c *gin.Context) (exportFormat, error) {
+	format := exportFormat{
+		DateFormat: c.Query("dateFormat"),
+		NumFormat:  c.Query("numFormat"),
+		BoolFormat: c.DefaultQuery("boolFormat", boolFormatText),
+		MaxRows:    initializers.GetConfig().ExportMaxRows,
+	}
+
+	if raw := c.Query("maxRows"); raw != "" {
+		maxRows, err := strconv.Atoi(raw)
+		if err != nil || maxRows < 1 || maxRows > format.MaxRows {
+			return exportFormat{}, fmt.Errorf("некорректный maxRows '%s', допустимо от 1 до %d", raw, format.MaxRows)
+		}
+		format.MaxRows = maxRows
+	}
+
+	if format.DateFormat != "" {
+		valid := false
+		for _, token := range dateLayoutTokens {
+			if strings.Contains(format.DateFormat, token) {
+				valid = true
+				break
+			}
+		}
+		if !valid || len(format.DateFormat) > 64 {
+			return exportFormat{}, fmt.Errorf("некорректный dateFormat '%s', пример: 2006-01-02", format.DateFormat)
+		}
+	}
+
+	if format.NumFormat != "" && !numFormatPattern.MatchString(format.NumFormat) {
+		return exportFormat{}, fmt.Errorf("некорректный numFormat '%s', пример: %%.2f", format.NumFormat)
+	}
+
+	if format.BoolFormat != boolFormatText && format.BoolFormat != boolFormatNumeric {
+		return exportFormat{}, fmt.Errorf("некорректный boolFormat '%s', допустимо %s или %s", format.BoolFormat, boolFormatText, boolFormatNumeric)
+	}
+
+	dialect, err := parseCSVDialect(c)
+	if err != nil {
+		return exportFormat{}, err
+	}
+	format.Dialect = dialect
+
+	if format.HeaderMap, err = parseHeaderMap(c.Query("headerMap")); err != nil {
+		return exportFormat{}, err
+	}
+
+	if raw := c.Query("columns"); raw != "" {
+		seen := map[string]bool{}
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				return exportFormat{}, fmt.Errorf("некорректный список columns: пустая или повторяющаяся колонка '%s'", name)
+			}
+			seen[name] = true
+			format.Columns = append(format.Columns, name)
+		}
+	}
+
+	return format, nil
+}
+
+// parseHeaderMap разбирает ?headerMap=column:Заголовок,column2:Заголовок 2
+func parseHeaderMap(raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	headerMap := map[string]string{}
+	used := map[string]string{}
+	for _, item := range strings.Split(raw, ",") {
+		column, label, ok := strings.Cut(item, ":")
+		column, label = strings.TrimSpace(column), strings.TrimSpace(label)
+		if !ok || column == "" || label == "" {
+			return nil, fmt.Errorf("некорректный элемент headerMap '%s', ожидается column:Заголовок", item)
+		}
+		if other, dup := used[label]; dup && other != column {
+			return nil, fmt.Errorf("заголовок '%s' указан для колонок %s и %s", label, other, column)
+		}
+		headerMap[column] = label
+		used[label] = column
+	}
+	return headerMap, nil
+}
+
+// checkColumns проверяет, что колонки из ?columns= и headerMap есть среди columns
+func (f exportFormat) checkColumns(columns []string) error {
+	known := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		known[col] = true
+	}
+	for _, column := range f.Columns {
+		if !known[column] {
+			return fmt.Errorf("колонка '%s' из columns не найдена", column)
+		}
+	}
+	for column := range f.HeaderMap {
+		if !known[column] {
+			return fmt.Errorf("колонка '%s' из headerMap не найдена", column)
+		}
+	}
+	return nil
+}
+
+// selectColumns возвращает выгружаемые колонки: из ?columns=, если он задан, иначе все
+func (f exportFormat) selectColumns(columns []string) []string {
+	if len(f.Columns) > 0 {
+		return f.Columns
+	}
+	return columns
+}
+
+// headers возвращает заголовки CSV для колонок с учетом HeaderMap
+func (f exportFormat) headers(columns []string) []string {
+	result := make([]string, len(columns))
+	for i, col := range columns {
+		if label, ok := f.HeaderMap[col]; ok {
+			result[i] = label
+		} else {
+			result[i] = col
+		}
+	}
+	return result
+}
+
+// markTruncated сообщает в заголовках ответа, что выгрузка обрезана по MaxRows.
+// Вызывается до записи данных; для файлов (бэкапы) ничего не делает
+func (f exportFormat) markTruncated(w io.Writer) {
+	if hw, ok := w.(interface{ Header() http.Header }); ok {
+		hw.Header().Set("X-Export-Truncated", "true")
+		hw.Header().Set("X-Export-Max-Rows", strconv.Itoa(f.MaxRows))
+	}
+}
+
+// formatNumber форматирует число по NumFormat; для %d дробная часть отбрасывается
+func (f exportFormat) formatNumber(n float64) string {
+	if strings.HasSuffix(f.NumFormat, "d") {
+		return fmt.Sprintf(f.NumFormat, int64(n))
+	}
+	return fmt.Sprintf(f.NumFormat, n)
+}
+
+// formatBool форматирует логическое значение по BoolFormat
+func (f exportFormat) formatBool(b bool) string {
+	if f.BoolFormat == boolFormatNumeric {
+		if b {
+			return "1"
+		}
+		return "0"
+	}
+	return strconv.FormatBool(b)
+}
+
+// formatValue превращает значение из БД в строку CSV.
+// dataType — тип колонки из information_schema, если известен
+func (f exportFormat) formatValue(val interface{}, dataType string) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		val = string(v)
+	case bool:
+		return f.formatBool(v)
+	case time.Time:
+		if f.DateFormat != "" {
+			return v.Format(f.DateFormat)
+		}
+		if dataType == "date" {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format(time.RFC3339)
+	}
+
+	if f.NumFormat != "" {
+		switch v := val.(type) {
+		case int:
+			return f.formatNumber(float64(v))
+		case int16:
+			return f.formatNumber(float64(v))
+		case int32:
+			return f.formatNumber(float64(v))
+		case int64:
+			return f.formatNumber(float64(v))
+		case float32:
+			return f.formatNumber(float64(v))
+		case float64:
+			return f.formatNumber(v)
+		case string:
+			// numeric приходит из драйвера строкой
+			if isFloatType(dataType) || isIntegerType(dataType) {
+				if n, err := strconv.ParseFloat(v, 64); err == nil {
+					return f.formatNumber(n)
+				}
+			}
+		}
+	}
+
+	// Без NumFormat дробные числа пишем полностью, без экспоненты (1e+06 -> 1000000)
+	switch v := val.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		if dataType == "boolean" {
+			if b, err := strconv.ParseBool(v); err == nil {
+				return f.formatBool(b)
+			}
+		}
+	}
+
+	return fmt.Sprintf("%v", val)
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestExportQueryColumnOrder проверяет, что без ?columns= колонки CSV идут в порядке SELECT
+func TestExportQueryColumnOrder(t *testing.T) {
+	testDB(t)
+	r := newTestRouter(func(r *gin.Engine) {
+		r.POST("/api/export/query", ExportQueryResults)
+	})
+
+	body := `{"query": "SELECT 3 AS zeta, 1 AS alpha, 2 AS mid, 4 AS beta"}`
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/export/query", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("экспорт: %d %s", w.Code, w.Body.String())
+		}
+
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != 2 || lines[0] != "zeta,alpha,mid,beta" || lines[1] != "3,1,2,4" {
+			t.Fatalf("CSV: %q, want заголовки zeta,alpha,mid,beta", lines)
+		}
+	}
+}
+
+// TestExportReservedTableName проверяет, что таблица и колонка с зарезервированными именами
+// выгружаются в CSV и в бэкап: имена экранируются в SQL и не попадают в пути файлов
+func TestExportReservedTableName(t *testing.T) {
+	db := testDB(t)
+	if exists, err := tableExists(db, "order"); err != nil || exists {
+		t.Skipf("таблица order уже есть в тестовой БД (err=%v)", err)
+	}
+	dropTestTable(t, db, "order")
+	if err := db.Exec(`CREATE TABLE "order" (id SERIAL PRIMARY KEY, "select" TEXT)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`INSERT INTO "order" ("select") VALUES ('a'), ('b')`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.GET("/api/export/:table", ExportTable)
+		r.GET("/api/tables/:name/backup", BackupTable)
+	})
+
+	for _, path := range []string{"/api/export/order", "/api/tables/order/backup"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", path, w.Code, w.Body.String())
+		}
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != 3 || lines[0] != "id,select" {
+			t.Fatalf("GET %s: CSV %q, want заголовок id,select и две строки", path, lines)
+		}
+	}
+}
+
+// TestExportQueryReadOnly проверяет, что выгрузка принимает только одиночный SELECT, не
+// отклоняет его за слова DROP/DELETE в именах и не дает изменить данные через CTE
+func TestExportQueryReadOnly(t *testing.T) {
+	setTestEnv(t, nil)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/export/query", ExportQueryResults)
+
+	for _, query := range []string{"DELETE FROM users", "SELECT 1; DROP TABLE users"} {
+		status, resp := doJSON(t, r, http.MethodPost, "/api/export/query", gin.H{"query": query})
+		if status != http.StatusBadRequest {
+			t.Errorf("%q: %d %v, want 400", query, status, resp)
+		}
+	}
+
+	db := testDB(t)
+	dropTestTable(t, db, "test_export_read_only")
+	if err := db.Exec("CREATE TABLE test_export_read_only (id int PRIMARY KEY, dropped_at text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("INSERT INTO test_export_read_only VALUES (1, 'x')")
+	r = newTestRouter(func(r *gin.Engine) {
+		r.POST("/api/export/query", ExportQueryResults)
+	})
+
+	status, resp := doJSON(t, r, http.MethodPost, "/api/export/query",
+		gin.H{"query": "WITH d AS (DELETE FROM test_export_read_only RETURNING *) SELECT * FROM d"})
+	if status != http.StatusForbidden {
+		t.Fatalf("WITH ... DELETE: %d %v, want 403", status, resp)
+	}
+
+	body := `{"query": "SELECT id, dropped_at FROM test_export_read_only"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/export/query", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "1,x") {
+		t.Fatalf("SELECT с dropped_at: %d %s, want строку 1,x", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// parseFieldsParam разбирает ?fields=a,b,c и возвращает множество выбранных колонок.
+// resolve сопоставляет имя из запроса с колонкой БД; неизвестные имена — ошибка 400
+func parseFieldsParam(raw string, resolve func(name string) (string, bool)) (map[string]bool, *apiError) {
+	selected := make(map[string]bool)
+	var unknown []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		column, ok := resolve(name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		selected[column] = true
+	}
+
+	if len(unknown) > 0 {
+		apiErr := newAPIError(http.StatusBadRequest, "Неизвестные поля в fields", nil)
+		apiErr.Body["unknown"] = unknown
+		return nil, apiErr
+	}
+	if len(selected) == 0 {
+		return nil, newAPIError(http.StatusBadRequest, "Параметр fields не содержит ни одного поля", nil)
+	}
+	return selected, nil
+}
+
+// projectColumns оставляет из columns выбранные и обязательные колонки, сохраняя порядок columns
+func projectColumns(columns []string, selected map[string]bool, required []string) []string {
+	keep := make(map[string]bool, len(selected)+len(required))
+	for column := range selected {
+		keep[column] = true
+	}
+	for _, column := range required {
+		keep[column] = true
+	}
+
+	projected := make([]string, 0, len(keep))
+	for _, column := range columns {
+		if keep[column] {
+			projected = append(projected, column)
+		}
+	}
+	return projected
+}
+
+// selectClause возвращает список колонок для SELECT
+func selectClause(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+	return strings.Join(quoted, ", ")
+}
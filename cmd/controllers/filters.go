@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// filterPattern разбирает фильтр вида column:op:value,
+// а также column->>'key':op:value и column#>>'{a,b}':op:value для JSON колонок
+var filterPattern = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)(?:(->>|#>>)'([^']*)')?:([a-z]+):(.*)$`)
+
+var filterOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "<>",
+	"gt":   ">",
+	"gte":  ">=",
+	"lt":   "<",
+	"lte":  "<=",
+	"like": "LIKE",
+}
+
+// tableFilter — разобранное условие фильтрации с привязанными параметрами
+type tableFilter struct {
+	Expr string
+	Args []interface{}
+}
+
+// parseFilter проверяет фильтр по схеме таблицы и строит условие WHERE.
+// types — карта колонка -> data_type
+func parseFilter(raw string, types map[string]string) (tableFilter, error) {
+	m := filterPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return tableFilter{}, fmt.Errorf("неверный формат фильтра '%s', ожидается column:op:value", raw)
+	}
+	column, jsonOp, jsonPath, op, value := m[1], m[2], m[3], m[4], m[5]
+
+	dataType, ok := types[column]
+	if !ok {
+		return tableFilter{}, fmt.Errorf("колонка '%s' не найдена", column)
+	}
+
+	sqlOp, ok := filterOperators[op]
+	if !ok {
+		return tableFilter{}, fmt.Errorf("недопустимый оператор '%s'", op)
+	}
+
+	if jsonOp == "" {
+		return tableFilter{
+			Expr: fmt.Sprintf("%s %s ?", quoteIdentifier(column), sqlOp),
+			Args: []interface{}{value},
+		}, nil
+	}
+
+	if !isJSONType(dataType) {
+		return tableFilter{}, fmt.Errorf("колонка '%s' имеет тип %s, JSON-фильтр недопустим", column, dataType)
+	}
+
+	// Путь передаем параметром: ->> принимает ключ, #>> — массив ключей вида {a,b}
+	var expr string
+	if jsonOp == "->>" {
+		expr = fmt.Sprintf("%s ->> ?", quoteIdentifier(column))
+	} else {
+		expr = fmt.Sprintf("%s #>> ?::text[]", quoteIdentifier(column))
+	}
+
+	return tableFilter{
+		Expr: fmt.Sprintf("(%s) %s ?", expr, sqlOp),
+		Args: []interface{}{jsonPath, value},
+	}, nil
+}
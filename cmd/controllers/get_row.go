@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// getPrimaryKeyColumns возвращает колонки первичного ключа в порядке их объявления в ключе
+func getPrimaryKeyColumns(db *gorm.DB, tableName string) ([]string, error) {
+	var columns []string
+	if err := db.Raw(`
+        SELECT a.attname
+        FROM pg_index i
+        JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+        WHERE i.indrelid = $1::regclass
+        AND i.indisprimary
+        ORDER BY array_position(i.indkey::int2[], a.attnum)
+    `, quoteIdentifier(tableName)).Scan(&columns).Error; err != nil {
+		return nil, primaryKeyLookupError(err)
+	}
+	if len(columns) == 0 {
+		return nil, errNoPrimaryKey
+	}
+	return columns, nil
+}
+
+// GetRow возвращает одну строку по первичному ключу со значениями, приведенными к JSON-типам.
+// :id — значение первой колонки ключа; для составного ключа остальные колонки
+// передаются параметрами запроса: GET /api/tables/orders/rows/10?line_no=2
+func GetRow(c *gin.Context) {
+	tableName := c.Param("name")
+
+	if !requireTable(c, tableName) {
+		return
+	}
+
+	pkColumns, err := getPrimaryKeyColumns(getDB(c), tableName)
+	if err != nil {
+		respondPrimaryKeyError(c, err)
+		return
+	}
+
+	// Собираем значения ключа: первое из пути, остальные из параметров запроса
+	key := make(map[string]string, len(pkColumns))
+	key[pkColumns[0]] = c.Param("id")
+	var missing []string
+	for _, col := range pkColumns[1:] {
+		value, ok := c.GetQuery(col)
+		if !ok || value == "" {
+			missing = append(missing, col)
+			continue
+		}
+		key[col] = value
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Не заданы значения составного первичного ключа",
+			"missing":    missing,
+			"primaryKey": pkColumns,
+		})
+		return
+	}
+
+	conditions := make([]string, 0, len(pkColumns))
+	args := make([]interface{}, 0, len(pkColumns))
+	for _, col := range pkColumns {
+		if err := validateRowID(getDB(c), tableName, col, key[col]); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "column": col})
+			return
+		}
+		conditions = append(conditions, quoteIdentifier(col)+" = ?")
+		args = append(args, key[col])
+	}
+
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	columns := make([]string, 0, len(columnTypes))
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		columns = append(columns, col.ColumnName)
+		types[col.ColumnName] = col.DataType
+	}
+	columns = applyColumnOrder(columns, getColumnOrder(getDB(c), tableName))
+
+	var rows []map[string]interface{}
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s LIMIT 1", quoteIdentifier(tableName), strings.Join(conditions, " AND "))
+	if err := getDB(c).Raw(query, args...).Scan(&rows).Error; err != nil {
+		respondError(c, err)
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Строка не найдена", "key": key})
+		return
+	}
+
+	row := rows[0]
+	normalizeRow(row, columns, types)
+	logTableRead(c, tableName, "read", 1)
+
+	c.JSON(http.StatusOK, gin.H{
+		"columns": columns,
+		"key":     key,
+		"data":    row,
+	})
+}
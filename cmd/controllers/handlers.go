@@ -2,16 +2,21 @@ package controllers
 
 import (
 	"archive/zip"
-	"encoding/csv"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -23,12 +28,28 @@ func isValidIdentifier(s string) bool {
 	return regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`).MatchString(s)
 }
 
-// CreateTable создает новую таблицу
+// CreateTable создает новую таблицу.
+//
+// Колонки задаются строками "name:type", модификатор ":pk" объявляет первичный ключ,
+// ":default=expr" задает значение по умолчанию (например, id:UUID:pk:default=gen_random_uuid()).
+// Первичный ключ определяется в порядке приоритета:
+//  1. колонка с ":pk" (допускается ровно одна);
+//  2. единственная колонка SERIAL становится первичным ключом;
+//  3. если autoId не равен false, добавляется "id SERIAL PRIMARY KEY".
+//
+// В итоге у таблицы должен быть ровно один первичный ключ, иначе запрос отклоняется.
+// Исключение — REQUIRE_PRIMARY_KEY=false: тогда autoId: false без ":pk" и SERIAL создает
+// таблицу без ключа (операции с отдельными строками для нее вернут 409).
+// autoId по умолчанию добавляет id независимо от REQUIRE_PRIMARY_KEY.
+// С timestamps: true добавляются created_at и updated_at, updated_at обновляется триггером.
+// С ?dryRun=true возвращается SQL, который был бы выполнен, без создания таблицы
 func CreateTable(c *gin.Context) {
 	// 1. Определяем структуру для входящего запроса
 	type Request struct {
-		Name    string   `json:"name" binding:"required"`
-		Columns []string `json:"columns" binding:"required,min=1,dive,required"`
+		Name       string   `json:"name" binding:"required"`
+		Columns    []string `json:"columns" binding:"required,min=1,dive,required"`
+		AutoID     *bool    `json:"autoId"`
+		Timestamps bool     `json:"timestamps"`
 	}
 
 	// 2. Парсим входящий JSON
@@ -41,24 +62,41 @@ func CreateTable(c *gin.Context) {
 		return
 	}
 
-	// 3. Валидация имени таблицы
-	if !isValidIdentifier(req.Name) {
+	req.Name = initializers.NormalizeIdentifier(req.Name)
+
+	// Число колонок ограничено CREATE_TABLE_MAX_COLUMNS — проверяем до разбора спецификаций
+	if maxColumns := initializers.GetConfig().CreateTableMaxColumns; len(req.Columns) > maxColumns {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":        "Некорректное имя таблицы",
-			"requirements": "Должно начинаться с буквы и содержать только a-z, 0-9, _",
-			"received":     req.Name,
+			"error":      "Слишком много колонок",
+			"columns":    len(req.Columns),
+			"maxColumns": maxColumns,
 		})
 		return
 	}
 
-	// 4. Проверяем существование таблицы
-	var tableExists bool
-	if err := initializers.DB.Raw(`
-        SELECT EXISTS (
-            SELECT FROM information_schema.tables 
-            WHERE table_name = ?
-        )`, strings.ToLower(req.Name)).Scan(&tableExists).Error; err != nil {
+	// 3. Валидация имени таблицы
+	if apiErr := validateTableName(req.Name); apiErr != nil {
+		respondError(c, apiErr)
+		return
+	}
+
+	if !initializers.TableAllowed(req.Name) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Доступ к таблице запрещен", "table": req.Name})
+		return
+	}
+
+	// Зарезервированные слова допустимы только в кавычках;
+	// в строгом режиме (?strict=true) отклоняем их сразу
+	strict := c.Query("strict") == "true"
+	var reserved []string
+	if isReservedWord(req.Name) {
+		reserved = append(reserved, req.Name)
+	}
 
+	// 4. Проверяем существование таблицы. Имя уже приведено к политике IDENTIFIER_CASE
+	// (по умолчанию lower), поэтому MyTable и mytable — одна таблица, как и без кавычек
+	exists, err := tableExists(getDB(c), req.Name)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Ошибка проверки существования таблицы",
 			"details": err.Error(),
@@ -66,7 +104,7 @@ func CreateTable(c *gin.Context) {
 		return
 	}
 
-	if tableExists {
+	if exists {
 		c.JSON(http.StatusConflict, gin.H{
 			"error": fmt.Sprintf("Таблица '%s' уже существует", req.Name),
 		})
@@ -74,40 +112,33 @@ func CreateTable(c *gin.Context) {
 	}
 
 	// 5. Обрабатываем колонки
-	var columns []string
-	var hasSerial bool
+	var specs []columnSpec
 	columnNames := make(map[string]bool)
-	validTypes := map[string]bool{
-		"INTEGER": true, "SERIAL": true, "VARCHAR(255)": true,
-		"TEXT": true, "BOOLEAN": true, "DATE": true,
-		"TIMESTAMP": true, "FLOAT": true, "JSON": true, "UUID": true,
-	}
 
 	for i, col := range req.Columns {
-		parts := strings.SplitN(col, ":", 2)
-		if len(parts) != 2 {
+		spec, err := parseColumnSpec(col)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":    "Неверный формат колонки",
+				"details":  err.Error(),
 				"position": i + 1,
-				"expected": "name:type",
+				"expected": "name:type[:pk][:default=expr]",
 				"example":  "price:FLOAT",
 			})
 			return
 		}
+		spec.Name = initializers.NormalizeIdentifier(spec.Name)
 
-		name, colType := parts[0], parts[1]
+		name, colType := spec.Name, spec.Type
 
 		// Проверка имени колонки
-		if !isValidIdentifier(name) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":    "Некорректное имя колонки",
-				"position": i + 1,
-				"name":     name,
-			})
+		if apiErr := validateColumnName(name, i+1); apiErr != nil {
+			respondError(c, apiErr)
 			return
 		}
 
-		// Проверка на дубликаты
+		// Проверка на дубликаты. Длина уже ограничена IDENTIFIER_MAX_LENGTH (не больше 63 байт),
+		// поэтому Postgres не обрежет имена и разные имена не совпадут после обрезки
 		if columnNames[name] {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":    "Дублирующееся имя колонки",
@@ -119,91 +150,224 @@ func CreateTable(c *gin.Context) {
 		columnNames[name] = true
 
 		// Проверка типа данных
-		if !validTypes[colType] {
+		if !validColumnTypes[colType] {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":    "Недопустимый тип данных",
 				"position": i + 1,
 				"type":     colType,
-				"allowed":  getKeys(validTypes),
+				"allowed":  getKeys(validColumnTypes),
 			})
 			return
 		}
 
-		if colType == "SERIAL" {
-			hasSerial = true
+		if isReservedWord(name) {
+			reserved = append(reserved, name)
 		}
 
-		columns = append(columns, fmt.Sprintf("%s %s", name, colType))
+		specs = append(specs, spec)
 	}
 
-	// 6. Добавляем первичный ключ, если нет SERIAL
-	if !hasSerial {
-		columns = append(columns, "id SERIAL PRIMARY KEY")
+	if strict && len(reserved) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Имена совпадают с зарезервированными словами SQL",
+			"reserved": reserved,
+		})
+		return
 	}
 
-	// 7. Формируем SQL запрос
-	sql := fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", req.Name, strings.Join(columns, ",\n  "))
-
-	// 8. Начинаем транзакцию
-	tx := initializers.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	// 6. Определяем первичный ключ (см. правила в описании функции)
+	var pkColumns, serialColumns []string
+	for _, spec := range specs {
+		if spec.PrimaryKey {
+			pkColumns = append(pkColumns, spec.Name)
+		}
+		if spec.Type == "SERIAL" {
+			serialColumns = append(serialColumns, spec.Name)
 		}
-	}()
+	}
 
-	// 9. Создаем таблицу
-	if err := tx.Exec(sql).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Ошибка выполнения SQL",
-			"details": err.Error(),
-			"sql":     sql,
+	autoID := req.AutoID == nil || *req.AutoID
+	addID := false
+	withoutPK := false
+	switch {
+	case len(pkColumns) > 1:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Допускается только один первичный ключ",
+			"columns": pkColumns,
 		})
 		return
-	}
-
-	// 10. Сохраняем метаданные
-	columnsJSON, err := json.Marshal(req.Columns)
-	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Ошибка сериализации колонок",
-			"details": err.Error(),
+	case len(pkColumns) == 1:
+		// Явно объявленный ключ
+	case len(serialColumns) == 1:
+		for i := range specs {
+			if specs[i].Name == serialColumns[0] {
+				specs[i].PrimaryKey = true
+			}
+		}
+	case len(serialColumns) > 1:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Несколько колонок SERIAL: укажите первичный ключ модификатором :pk",
+			"columns": serialColumns,
+		})
+		return
+	case autoID:
+		if columnNames["id"] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Колонка id уже объявлена: пометьте ее :pk или передайте autoId: false с другим ключом",
+			})
+			return
+		}
+		addID = true
+	case !initializers.GetConfig().RequirePrimaryKey:
+		// Таблица без первичного ключа разрешена настройкой
+		withoutPK = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "При autoId: false нужно объявить первичный ключ модификатором :pk",
+			"hint":  "Таблицы без первичного ключа разрешаются настройкой REQUIRE_PRIMARY_KEY=false",
 		})
 		return
 	}
 
-	meta := model.TableMeta{
-		Name:    req.Name,
-		Columns: string(columnsJSON),
+	columns := make([]string, 0, len(specs)+1)
+	for _, spec := range specs {
+		columns = append(columns, spec.definition())
+	}
+	if addID {
+		columns = append(columns, "id SERIAL PRIMARY KEY")
+	}
+	if req.Timestamps {
+		if columnNames["created_at"] || columnNames["updated_at"] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Колонки created_at и updated_at добавляются автоматически при timestamps: true",
+			})
+			return
+		}
+		columns = append(columns, timestampColumns...)
 	}
 
-	if err := tx.Create(&meta).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Ошибка сохранения метаданных",
-			"details": err.Error(),
+	// Функции в значениях по умолчанию (например, gen_random_uuid()) должны существовать в БД
+	for _, spec := range specs {
+		fn := spec.defaultFunction()
+		if fn == "" {
+			continue
+		}
+
+		var fnExists bool
+		if err := getDB(c).Raw(`
+			SELECT EXISTS (
+				SELECT FROM pg_proc WHERE proname = ?
+			)`, fn).Scan(&fnExists).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Ошибка проверки функции значения по умолчанию",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		if !fnExists {
+			response := gin.H{
+				"error":    fmt.Sprintf("Функция %s() недоступна в базе данных", fn),
+				"column":   spec.Name,
+				"function": fn,
+			}
+			if ext, ok := functionExtensions[fn]; ok {
+				response["hint"] = fmt.Sprintf("Установите расширение %s", ext)
+			}
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+	}
+
+	// 7. Формируем SQL запрос
+	sql := fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", quoteIdentifier(req.Name), strings.Join(columns, ",\n  "))
+	if maxLength := initializers.GetConfig().CreateTableMaxSQLLength; len(sql) > maxLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Слишком длинное определение таблицы",
+			"length":    len(sql),
+			"maxLength": maxLength,
 		})
 		return
 	}
 
-	// 11. Фиксируем транзакцию
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Ошибка фиксации транзакции",
-			"details": err.Error(),
-		})
+	// С ?dryRun=true все проверки выполнены, но таблица и метаданные не создаются
+	if c.Query("dryRun") == "true" {
+		response := gin.H{
+			"status":  "Проверка пройдена, таблица не создана",
+			"dryRun":  true,
+			"table":   req.Name,
+			"columns": columns,
+			"sql":     sql,
+		}
+		if len(reserved) > 0 {
+			response["warning"] = "Использованы зарезервированные слова SQL, они экранированы кавычками"
+			response["reserved"] = reserved
+		}
+		if withoutPK {
+			response["notice"] = noPrimaryKeyNotice
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// 8. Создаем таблицу и метаданные в одной транзакции
+	var meta model.TableMeta
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, req.Name); err != nil {
+			return err
+		}
+
+		// 9. Создаем таблицу
+		if err := tx.Exec(sql).Error; err != nil {
+			apiErr := newAPIError(http.StatusInternalServerError, "Ошибка выполнения SQL", err)
+			apiErr.Body["sql"] = sql
+			return apiErr
+		}
+
+		if req.Timestamps {
+			if err := createUpdatedAtTrigger(tx, req.Name); err != nil {
+				return newAPIError(http.StatusInternalServerError, "Ошибка создания триггера updated_at", err)
+			}
+		}
+
+		// 10. Сохраняем метаданные
+		columnsJSON, err := json.Marshal(req.Columns)
+		if err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка сериализации колонок", err)
+		}
+
+		meta = model.TableMeta{
+			Name:       req.Name,
+			Columns:    string(columnsJSON),
+			Timestamps: req.Timestamps,
+		}
+
+		if err := tx.Create(&meta).Error; err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка сохранения метаданных", err)
+		}
+		return nil
+	})
+
+	// 11. Транзакция зафиксирована в WithTransaction
+	if !ok {
 		return
 	}
 
 	// 12. Возвращаем успешный ответ
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"status":  "Таблица успешно создана",
 		"table":   req.Name,
 		"meta_id": meta.ID,
 		"columns": columns,
-	})
+	}
+	if len(reserved) > 0 {
+		response["warning"] = "Использованы зарезервированные слова SQL, они экранированы кавычками"
+		response["reserved"] = reserved
+	}
+	if withoutPK {
+		response["notice"] = noPrimaryKeyNotice
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // Вспомогательные функции
@@ -219,20 +383,93 @@ func getKeys(m map[string]bool) []string {
 	return keys
 }
 
-// ListTables возвращает список таблиц
+// tableListItem — элемент списка таблиц с дополнительными сведениями
+type tableListItem struct {
+	Name         string `gorm:"column:table_name" json:"name"`
+	RowsEstimate *int64 `gorm:"column:rows_estimate" json:"rowsEstimate,omitempty"`
+	SizeBytes    *int64 `gorm:"column:size_bytes" json:"sizeBytes,omitempty"`
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ListTables возвращает список таблиц.
+// Фильтры: ?prefix=, ?search= (подстрока имени). С ?withCounts=true и/или ?withSize=true
+// возвращаются объекты {name, rowsEstimate, sizeBytes} (оценки из pg_class), иначе — строки
 func ListTables(c *gin.Context) {
-	var tables []string
-	if err := initializers.DB.Raw(`
-		SELECT table_name 
-		FROM information_schema.tables 
-		WHERE table_schema = 'public'
-		ORDER BY table_name
-	`).Scan(&tables).Error; err != nil {
+	page, paginate := getPagination(c)
+	withCounts := c.Query("withCounts") == "true"
+	withSize := c.Query("withSize") == "true"
+
+	where := "t.table_schema = 'public'"
+	var args []interface{}
+	if prefix := c.Query("prefix"); prefix != "" {
+		where += " AND t.table_name LIKE ?"
+		args = append(args, escapeLike(prefix)+"%")
+	}
+	if search := c.Query("search"); search != "" {
+		where += " AND t.table_name ILIKE ?"
+		args = append(args, "%"+escapeLike(search)+"%")
+	}
+	if cond, condArgs := allowlistCondition("t.table_name"); cond != "" {
+		where += " AND " + cond
+		args = append(args, condArgs...)
+	}
+
+	selectColumns := "t.table_name"
+	from := "information_schema.tables t"
+	if withCounts || withSize {
+		from += `
+		LEFT JOIN pg_class pc ON pc.relname = t.table_name
+			AND pc.relnamespace = 'public'::regnamespace`
+	}
+	if withCounts {
+		selectColumns += ", GREATEST(pc.reltuples, 0)::bigint AS rows_estimate"
+	}
+	if withSize {
+		selectColumns += ", pg_total_relation_size(pc.oid) AS size_bytes"
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY t.table_name", selectColumns, from, where)
+	queryArgs := args
+	if paginate {
+		query += " LIMIT ? OFFSET ?"
+		queryArgs = append(append([]interface{}{}, args...), page.PageSize, page.Offset())
+	}
+
+	var data interface{}
+	if withCounts || withSize {
+		var items []tableListItem
+		if err := getDB(c).Raw(query, queryArgs...).Scan(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения списка таблиц"})
+			return
+		}
+		data = items
+	} else {
+		var tables []string
+		if err := getDB(c).Raw(query, queryArgs...).Scan(&tables).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения списка таблиц"})
+			return
+		}
+		data = tables
+	}
+
+	if !paginate {
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	var total int64
+	if err := getDB(c).Raw(
+		fmt.Sprintf("SELECT COUNT(*) FROM information_schema.tables t WHERE %s", where), args...,
+	).Scan(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения списка таблиц"})
 		return
 	}
 
-	c.JSON(http.StatusOK, tables)
+	c.JSON(http.StatusOK, paginatedResponse(data, page, total))
 }
 
 // GetTableInfo возвращает информацию о таблице
@@ -240,7 +477,7 @@ func GetTableInfo(c *gin.Context) {
 	tableName := c.Param("name")
 
 	var meta model.TableMeta
-	if err := initializers.DB.Where("name = ?", tableName).First(&meta).Error; err != nil {
+	if err := getDB(c).Where("name = ?", tableName).First(&meta).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Метаданные таблицы не найдены"})
 		return
 	}
@@ -249,9 +486,11 @@ func GetTableInfo(c *gin.Context) {
 	var columns []struct {
 		ColumnName string `gorm:"column:column_name"`
 		DataType   string `gorm:"column:data_type"`
+		NullCount  *int64 `gorm:"-" json:",omitempty"`
+		EmptyCount *int64 `gorm:"-" json:",omitempty"`
 	}
 
-	if err := initializers.DB.Raw(`
+	if err := getDB(c).Raw(`
 		SELECT column_name, data_type
 		FROM information_schema.columns
 		WHERE table_name = ?
@@ -261,10 +500,73 @@ func GetTableInfo(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	// Применяем порядок отображения из метаданных
+	var order []string
+	if meta.ColumnOrder != "" {
+		json.Unmarshal([]byte(meta.ColumnOrder), &order)
+	}
+	if len(order) > 0 {
+		byName := make(map[string]int, len(columns))
+		names := make([]string, len(columns))
+		for i, col := range columns {
+			byName[col.ColumnName] = i
+			names[i] = col.ColumnName
+		}
+		ordered := columns[:0:0]
+		for _, name := range applyColumnOrder(names, order) {
+			ordered = append(ordered, columns[byName[name]])
+		}
+		columns = ordered
+	}
+
+	response := gin.H{
 		"name":    meta.Name,
 		"columns": columns,
-	})
+	}
+
+	// С ?withQuality=true считаем NULL и пустые строки по каждой колонке одним запросом.
+	// Запрос читает всю таблицу, поэтому только по флагу
+	if c.Query("withQuality") == "true" && len(columns) > 0 {
+		selects := []string{"count(*) AS total"}
+		for i, col := range columns {
+			column := quoteIdentifier(col.ColumnName)
+			selects = append(selects,
+				fmt.Sprintf("count(*) - count(%s) AS n%d", column, i),
+				fmt.Sprintf("count(*) FILTER (WHERE %s::text = '') AS e%d", column, i))
+		}
+
+		var counts map[string]interface{}
+		sql := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), quoteIdentifier(tableName))
+		if err := getDB(c).Raw(sql).Scan(&counts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка подсчета NULL значений", "details": err.Error()})
+			return
+		}
+
+		for i := range columns {
+			nulls := toInt64(counts[fmt.Sprintf("n%d", i)])
+			empty := toInt64(counts[fmt.Sprintf("e%d", i)])
+			columns[i].NullCount = &nulls
+			columns[i].EmptyCount = &empty
+		}
+		response["rowCount"] = toInt64(counts["total"])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// toInt64 приводит результат агрегатной функции из map-сканирования к int64
+func toInt64(val interface{}) int64 {
+	switch v := val.(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
 }
 
 // DropTable удаляет таблицу
@@ -272,81 +574,60 @@ func DropTable(c *gin.Context) {
 	tableName := c.Param("name")
 
 	// Проверяем существование таблицы
-	var exists bool
-	if err := initializers.DB.Raw(`
-		SELECT EXISTS (
-			SELECT FROM information_schema.tables 
-			WHERE table_name = ?
-		)`, tableName).Scan(&exists).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки таблицы"})
+	if !requireTable(c, tableName) {
 		return
 	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Таблица не найдена"})
-		return
-	}
+	sql := fmt.Sprintf("DROP TABLE %s", quoteIdentifier(tableName))
 
 	// Удаляем в транзакции
-	tx := initializers.DB.Begin()
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
 
-	// Удаляем метаданные
-	if err := tx.Where("name = ?", tableName).Delete(&model.TableMeta{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления метаданных"})
-		return
-	}
+		// Удаляем метаданные
+		if err := tx.Where("name = ?", tableName).Delete(&model.TableMeta{}).Error; err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка удаления метаданных", err)
+		}
 
-	// Удаляем таблицу
-	if err := tx.Exec(fmt.Sprintf("DROP TABLE %s", tableName)).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления таблицы"})
+		// Удаляем таблицу
+		if err := tx.Exec(sql).Error; err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка удаления таблицы", err)
+		}
+		return nil
+	})
+	if !ok {
 		return
 	}
 
-	tx.Commit()
-
-	c.JSON(http.StatusOK, gin.H{"status": "Таблица удалена"})
+	c.JSON(http.StatusOK, withDebugSQL(c, gin.H{"status": "Таблица удалена"}, sql))
 }
 
+// BackupDB выгружает все таблицы в zip-архив:
+//   - обычный режим: <таблица>.csv с данными и _metadata.json;
+//   - ?schemaOnly=true: <таблица>.sql с CREATE TABLE и индексами и _metadata.json, без данных.
+//
+// RestoreDB принимает оба варианта: .csv восстанавливает таблицу с данными, .sql — пустую таблицу
 func BackupDB(c *gin.Context) {
-	// Создаем временный файл
-	backupFile := fmt.Sprintf("backup_%s.zip", time.Now().Format("20060102_150405"))
-	zipFile, err := os.Create(backupFile)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать файл бэкапа"})
-		return
+	// Получаем список таблиц
+	where := "table_schema = 'public'"
+	var args []interface{}
+	if cond, condArgs := allowlistCondition("table_name"); cond != "" {
+		where += " AND " + cond
+		args = condArgs
 	}
-	defer os.Remove(backupFile)
-	defer zipFile.Close()
 
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
-
-	// Получаем список таблиц
 	var tables []string
-	if err := initializers.DB.Raw(`
-        SELECT table_name 
-        FROM information_schema.tables 
-        WHERE table_schema = 'public'
-    `).Scan(&tables).Error; err != nil {
+	if err := getDB(c).Raw(
+		"SELECT table_name FROM information_schema.tables WHERE "+where, args...,
+	).Scan(&tables).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения списка таблиц"})
 		return
 	}
 
 	// Экспортируем каждую таблицу
-	for _, table := range tables {
-		file, err := zipWriter.Create(table + ".csv")
-		if err != nil {
-			continue
-		}
-
-		if err := exportTableToWriter(table, file); err != nil {
-			continue
-		}
-	}
-
-	c.FileAttachment(backupFile, "db_backup.zip")
+	streamBackupArchive(c, "db_backup.zip", tables, c.Query("schemaOnly") == "true")
 }
 
 // RestoreDB восстанавливает базу из резервной копии
@@ -371,23 +652,19 @@ func RestoreDB(c *gin.Context) {
 	}
 
 	// Распаковываем архив
-	zipReader, err := zip.OpenReader(tempFile.Name())
+	zipReader, err := openZipUpload(tempFile.Name())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат архива"})
+		respondError(c, err)
 		return
 	}
 	defer zipReader.Close()
 
-	// Восстанавливаем в транзакции
-	tx := initializers.DB.Begin()
-
-	// Сначала восстанавливаем метаданные
+	// Сначала читаем метаданные
 	var metas []model.TableMeta
 	for _, f := range zipReader.File {
 		if f.Name == "_metadata.json" {
 			rc, err := f.Open()
 			if err != nil {
-				tx.Rollback()
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения метаданных"})
 				return
 			}
@@ -399,120 +676,325 @@ func RestoreDB(c *gin.Context) {
 		}
 	}
 
-	// Затем таблицы
+	// Все таблицы архива должны быть разрешены TABLE_ALLOWLIST
+	var archiveTables []string
 	for _, f := range zipReader.File {
-		if !strings.HasSuffix(f.Name, ".csv") || f.Name == "_metadata.json" {
-			continue
+		if ext := path.Ext(f.Name); ext == ".csv" || ext == ".sql" {
+			archiveTables = append(archiveTables, strings.TrimSuffix(f.Name, ext))
 		}
+	}
+	if forbidden := forbiddenTables(archiveTables); len(forbidden) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Доступ к таблицам запрещен", "tables": forbidden})
+		return
+	}
 
-		tableName := strings.TrimSuffix(f.Name, ".csv")
-		if err := restoreTableFromZip(tx, f, tableName); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": fmt.Sprintf("Ошибка восстановления таблицы %s: %v", tableName, err),
-			})
-			return
-		}
+	// С ?continueOnError=true каждая таблица восстанавливается в своей точке сохранения:
+	// ошибка откатывает только эту таблицу, остальные фиксируются
+	continueOnError := c.Query("continueOnError") == "true"
+	// С ?dataOnly=true таблицы не пересоздаются, а строки добавляются в существующие
+	dataOnly := c.Query("dataOnly") == "true"
+	// Диалект CSV-файлов архива (?delimiter=, ?quote=, ?lazyQuotes=true)
+	dialect, err := parseCSVDialect(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	var report []tableRestoreResult
+	failed := map[string]bool{}
 
-	// Восстанавливаем метаданные
-	if len(metas) > 0 {
-		if err := tx.Where("1=1").Delete(&model.TableMeta{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка очистки старых метаданных"})
-			return
+	// Восстанавливаем в транзакции
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		// Затем таблицы
+		for _, f := range zipReader.File {
+			ext := path.Ext(f.Name)
+			if ext != ".csv" && ext != ".sql" {
+				continue
+			}
+
+			tableName := strings.TrimSuffix(f.Name, ext)
+			var declared map[string]string
+			for _, meta := range metas {
+				if meta.Name == tableName {
+					declared = declaredColumnTypes(meta)
+					break
+				}
+			}
+
+			// .sql — таблица из schema-only архива, восстанавливается пустой.
+			// При dataOnly структура не трогается: .sql пропускается, строки CSV добавляются
+			restore := func() error {
+				switch {
+				case ext == ".sql" && dataOnly:
+					return nil
+				case ext == ".sql":
+					return restoreSchemaFromZip(tx, f, tableName)
+				case dataOnly:
+					return restoreTableDataFromZip(tx, f, tableName, dialect)
+				}
+				return restoreTableFromZip(tx, f, tableName, declared, dialect)
+			}
+
+			if !continueOnError {
+				if err := restore(); err != nil {
+					return newAPIError(http.StatusInternalServerError,
+						fmt.Sprintf("Ошибка восстановления таблицы %s: %v", tableName, err), nil)
+				}
+				continue
+			}
+
+			if err := tx.SavePoint("restore_table").Error; err != nil {
+				return newAPIError(http.StatusInternalServerError, "Ошибка создания точки сохранения", err)
+			}
+			if err := restore(); err != nil {
+				if rbErr := tx.RollbackTo("restore_table").Error; rbErr != nil {
+					return newAPIError(http.StatusInternalServerError, "Ошибка отката к точке сохранения", rbErr)
+				}
+				failed[tableName] = true
+				report = append(report, tableRestoreResult{Table: tableName, Error: err.Error()})
+				continue
+			}
+			report = append(report, tableRestoreResult{Table: tableName, Restored: true})
 		}
 
-		for _, meta := range metas {
-			if err := tx.Create(&meta).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": fmt.Sprintf("Ошибка сохранения метаданных для %s", meta.Name),
-				})
-				return
+		// Восстанавливаем метаданные; у не восстановленных таблиц остаются прежние.
+		// При dataOnly структура не менялась, и метаданные остаются текущими
+		if len(metas) > 0 && !dataOnly {
+			stale := tx.Where("1=1")
+			if len(failed) > 0 {
+				stale = tx.Where("name NOT IN ?", getKeys(failed))
+			}
+			if err := stale.Delete(&model.TableMeta{}).Error; err != nil {
+				return newAPIError(http.StatusInternalServerError, "Ошибка очистки старых метаданных", err)
 			}
+
+			for _, meta := range metas {
+				if failed[meta.Name] {
+					continue
+				}
+				if err := tx.Create(&meta).Error; err != nil {
+					return newAPIError(http.StatusInternalServerError,
+						fmt.Sprintf("Ошибка сохранения метаданных для %s", meta.Name), err)
+				}
+			}
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	if continueOnError {
+		status := "База восстановлена"
+		if len(failed) > 0 {
+			status = "База восстановлена частично"
 		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": status,
+			"failed": len(failed),
+			"tables": report,
+		})
+		return
 	}
 
-	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"status": "База восстановлена"})
 }
 
-// AlterTable изменяет структуру таблицы
-func AlterTable(c *gin.Context) {
+// tableRestoreResult — результат восстановления одной таблицы при ?continueOnError=true
+type tableRestoreResult struct {
+	Table    string `json:"table"`
+	Restored bool   `json:"restored"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AlterColumn изменяет колонку :column. Действие задается полем action:
+//   - rename: переименовать в newName;
+//   - setType: сменить тип на type (значения приводятся через USING);
+//   - setDefault: задать default (null — удалить значение по умолчанию);
+//   - setNotNull: notNull=true запрещает NULL (если NULL уже есть — 409), false разрешает
+func AlterColumn(c *gin.Context) {
+	tableName := c.Param("name")
+	columnName := c.Param("column")
+
 	var req struct {
-		Action string `json:"action" binding:"required"` // "add" или "drop"
-		Column string `json:"column" binding:"required"`
-		Type   string `json:"type,omitempty"` // Только для action=add
+		Action  string          `json:"action" binding:"required"`
+		NewName string          `json:"newName"`
+		Type    string          `json:"type"`
+		Default json.RawMessage `json:"default"`
+		NotNull *bool           `json:"notNull"`
 	}
-
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	table := c.Param("name")
-
-	// Проверка существования таблицы
-	var exists bool
-	initializers.DB.Raw(`
-		SELECT EXISTS (
-			SELECT FROM information_schema.tables 
-			WHERE table_name = ?
-		)`, table).Scan(&exists)
-
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Таблица не найдена"})
+	if !isValidIdentifier(tableName) || !isValidIdentifier(columnName) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректное имя таблицы или колонки"})
 		return
 	}
 
-	var sql string
-	switch req.Action {
-	case "add":
-		if req.Type == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Не указан тип колонки"})
-			return
-		}
-		sql = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, req.Column, req.Type)
-	case "drop":
-		sql = fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, req.Column)
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимое действие"})
+	if !requireTable(c, tableName) {
 		return
 	}
 
-	if err := initializers.DB.Exec(sql).Error; err != nil {
+	// Проверяем, что колонка существует
+	exists, err := columnExists(getDB(c), tableName, columnName)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "Таблица изменена"})
-}
-
-func SaveQuery(c *gin.Context) {
-	var req struct {
-		Query string `json:"query" binding:"required"`
-		Name  string `json:"name"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Колонка не найдена", "column": columnName})
 		return
 	}
 
-	// Проверяем существование запроса
-	var query model.SavedQuery
-	result := initializers.DB.Where("query = ?", req.Query).First(&query)
+	table := quoteIdentifier(tableName)
+	column := quoteIdentifier(columnName)
 
-	if result.Error == gorm.ErrRecordNotFound {
-		// Создаем новый запрос
-		query = model.SavedQuery{
-			Query:    req.Query,
-			Name:     req.Name,
+	var sql string
+	var syncMeta func(meta *model.TableMeta)
+	switch req.Action {
+	case "rename":
+		req.NewName = initializers.NormalizeIdentifier(req.NewName)
+		if !isValidIdentifier(req.NewName) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректное новое имя колонки", "newName": req.NewName})
+			return
+		}
+		if err := checkIdentifierLength(req.NewName); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Слишком длинное имя колонки", "details": err.Error()})
+			return
+		}
+
+		targetExists, err := columnExists(getDB(c), tableName, req.NewName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if targetExists {
+			c.JSON(http.StatusConflict, gin.H{"error": "Колонка уже существует", "name": req.NewName})
+			return
+		}
+		sql = fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, column, quoteIdentifier(req.NewName))
+		syncMeta = func(meta *model.TableMeta) {
+			renameColumnInMeta(meta, columnName, req.NewName)
+		}
+	case "setType":
+		if !validColumnTypes[req.Type] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Недопустимый тип данных",
+				"type":    req.Type,
+				"allowed": getKeys(validColumnTypes),
+			})
+			return
+		}
+		sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s", table, column, req.Type, column, req.Type)
+		syncMeta = func(meta *model.TableMeta) {
+			setColumnTypeInMeta(meta, columnName, req.Type)
+		}
+	case "setDefault":
+		if req.Default == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Не указано значение default"})
+			return
+		}
+		defaultSQL, err := parseDefaultLiteral(req.Default)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if defaultSQL != nil {
+			sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", table, column, *defaultSQL)
+		} else {
+			sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, column)
+		}
+		syncMeta = func(meta *model.TableMeta) {
+			updateColumnOptions(meta, columnName, func(opt *model.ColumnOptions) {
+				opt.Default = defaultSQL
+			})
+		}
+	case "setNotNull":
+		if req.NotNull == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Не указано значение notNull"})
+			return
+		}
+		if *req.NotNull {
+			sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", table, column)
+		} else {
+			sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", table, column)
+		}
+		nullable := !*req.NotNull
+		syncMeta = func(meta *model.TableMeta) {
+			updateColumnOptions(meta, columnName, func(opt *model.ColumnOptions) {
+				opt.Nullable = &nullable
+			})
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Недопустимое действие",
+			"allowed": []string{"rename", "setType", "setDefault", "setNotNull"},
+		})
+		return
+	}
+
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+
+		// Перед SET NOT NULL проверяем, что в колонке нет NULL
+		if req.Action == "setNotNull" && *req.NotNull {
+			var nullCount int64
+			if err := tx.Raw(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IS NULL", table, column)).Scan(&nullCount).Error; err != nil {
+				return err
+			}
+			if nullCount > 0 {
+				apiErr := newAPIError(http.StatusConflict, "В колонке есть NULL значения", nil)
+				apiErr.Body["nullCount"] = nullCount
+				return apiErr
+			}
+		}
+
+		if err := tx.Exec(sql).Error; err != nil {
+			apiErr := newAPIError(http.StatusBadRequest, "Ошибка выполнения SQL", err)
+			withDebugSQL(c, apiErr.Body, sql)
+			return apiErr
+		}
+
+		if err := updateTableMeta(tx, tableName, syncMeta); err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка сохранения метаданных", err)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, withDebugSQL(c, gin.H{"status": "Колонка изменена"}, sql))
+}
+
+func SaveQuery(c *gin.Context) {
+	var req struct {
+		Query string `json:"query" binding:"required"`
+		Name  string `json:"name"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Проверяем существование запроса
+	var query model.SavedQuery
+	result := getDB(c).Where("query = ?", req.Query).First(&query)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		// Создаем новый запрос
+		query = model.SavedQuery{
+			Query:    req.Query,
+			Name:     req.Name,
 			LastUsed: time.Now(),
 			UseCount: 1,
 		}
-		if err := initializers.DB.Create(&query).Error; err != nil {
+		if err := getDB(c).Create(&query).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -523,7 +1005,7 @@ func SaveQuery(c *gin.Context) {
 		if req.Name != "" {
 			query.Name = req.Name
 		}
-		if err := initializers.DB.Save(&query).Error; err != nil {
+		if err := getDB(c).Save(&query).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -539,9 +1021,76 @@ func SaveQuery(c *gin.Context) {
 	})
 }
 
+// UpdateQuery переименовывает сохраненный запрос и/или меняет его текст: {"name"?, "query"?}.
+// Текст нормализуется (пробелы и завершающие ';') и не должен совпадать с другим сохраненным запросом
+func UpdateQuery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный id запроса", "received": c.Param("id")})
+		return
+	}
+
+	var req struct {
+		Name  *string `json:"name"`
+		Query *string `json:"query"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == nil && req.Query == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нужно указать name и/или query"})
+		return
+	}
+
+	var query model.SavedQuery
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := tx.First(&query, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				apiErr := newAPIError(http.StatusNotFound, "Запрос не найден", nil)
+				apiErr.Body["id"] = id
+				return apiErr
+			}
+			return err
+		}
+
+		if req.Name != nil {
+			query.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.Query != nil {
+			text := trimStatement(*req.Query)
+			if text == "" {
+				return newAPIError(http.StatusBadRequest, "Текст запроса пуст", nil)
+			}
+
+			var duplicate model.SavedQuery
+			err := tx.Where("query = ? AND id <> ?", text, query.ID).First(&duplicate).Error
+			if err == nil {
+				apiErr := newAPIError(http.StatusConflict, "Такой запрос уже сохранен", nil)
+				apiErr.Body["id"] = duplicate.ID
+				return apiErr
+			}
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+			query.Query = text
+		}
+
+		if err := tx.Save(&query).Error; err != nil {
+			return dbWriteError(err)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, query)
+}
+
 func ListQueries(c *gin.Context) {
 	var queries []model.SavedQuery
-	if err := initializers.DB.
+	if err := getDB(c).
 		Order("last_used DESC").
 		Find(&queries).
 		Error; err != nil {
@@ -551,10 +1100,66 @@ func ListQueries(c *gin.Context) {
 	c.JSON(http.StatusOK, queries)
 }
 
-// ExecQuery выполняет SQL-запрос
+// previewRowLimit — сколько строк возвращает ExecuteQuery в режиме ?preview=true
+const previewRowLimit = 100
+
+// scanRowsLimited выполняет запрос и читает не больше maxRows строк.
+// Второе значение сообщает, что строк было больше и чтение остановлено.
+// Если для запроса задан statement_timeout, вне транзакции запрос выполняется в своей
+// транзакции, чтобы SET LOCAL ограничил его на стороне Postgres
+func scanRowsLimited(db *gorm.DB, maxRows int, query string, args ...interface{}) ([]map[string]interface{}, bool, error) {
+	_, results, truncated, err := scanRowsWithColumns(db, maxRows, query, args...)
+	return results, truncated, err
+}
+
+// scanRowsWithColumns — scanRowsLimited, который возвращает и колонки результата в порядке
+// SELECT: у строк-map порядка ключей нет
+func scanRowsWithColumns(db *gorm.DB, maxRows int, query string, args ...interface{}) ([]string, []map[string]interface{}, bool, error) {
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); !inTx && initializers.StatementTimeout(db.Statement.Context) > 0 {
+		tx := initializers.BeginTx(db)
+		if tx.Error != nil {
+			return nil, nil, false, tx.Error
+		}
+		columns, results, truncated, err := scanRowsWithColumns(tx, maxRows, query, args...)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, false, err
+		}
+		return columns, results, truncated, tx.Commit().Error
+	}
+
+	rows, err := db.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var results []map[string]interface{}
+	for rows.Next() {
+		if maxRows > 0 && len(results) >= maxRows {
+			return columns, results, true, nil
+		}
+
+		row := map[string]interface{}{}
+		if err := db.ScanRows(rows, &row); err != nil {
+			return nil, nil, false, err
+		}
+		results = append(results, row)
+	}
+
+	return columns, results, false, rows.Err()
+}
+
+// ExecQuery выполняет SQL-запрос. Значения для плейсхолдеров ? передаются в args
 func ExecuteQuery(c *gin.Context) {
 	var req struct {
-		Query string `json:"query" binding:"required"`
+		Query string        `json:"query" binding:"required"`
+		Args  []interface{} `json:"args"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -564,30 +1169,176 @@ func ExecuteQuery(c *gin.Context) {
 
 	// 1. Сначала обновляем статистику
 	var query model.SavedQuery
-	result := initializers.DB.Where("query = ?", req.Query).First(&query)
+	result := getDB(c).Where("query = ?", req.Query).First(&query)
 
 	if result.Error == nil {
 		// Запрос существует - обновляем статистику
 		query.LastUsed = time.Now()
 		query.UseCount += 1
-		initializers.DB.Save(&query)
+		getDB(c).Save(&query)
+	}
+
+	runQuery(c, req.Query, req.Args, query)
+}
+
+// ExecuteSavedQuery выполняет сохраненный запрос по id, как ExecuteQuery.
+// Тело запроса необязательно: {"args": [...]} — значения для плейсхолдеров ?
+func ExecuteSavedQuery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный id запроса", "received": c.Param("id")})
+		return
+	}
+
+	var req struct {
+		Args []interface{} `json:"args"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var query model.SavedQuery
+	if err := getDB(c).First(&query, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Запрос не найден", "id": id})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	query.LastUsed = time.Now()
+	query.UseCount += 1
+	getDB(c).Save(&query)
+
+	runQuery(c, query.Query, req.Args, query)
+}
+
+// runQuery выполняет запрос с параметрами и отправляет результат.
+// query — сохраненный запрос для queryInfo (пустой, если запрос не сохранен).
+// В режиме только для чтения запрос выполняется в транзакции только для чтения
+func runQuery(c *gin.Context, queryText string, args []interface{}, query model.SavedQuery) {
+	readOnly := initializers.GetConfig().ReadOnlyMode
+	if readOnly && !isSelectQuery(queryText) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "В режиме только для чтения разрешены только SELECT",
+			"kind":  statementKind(queryText),
+		})
+		return
+	}
+
+	// При ENFORCE_SELECT_LIMIT=true SELECT без LIMIT выполняется только с ?allowUnbounded=true
+	if initializers.GetConfig().EnforceSelectLimit &&
+		c.Query("allowUnbounded") != "true" && c.Query("preview") != "true" &&
+		isSelectQuery(queryText) && !hasTopLevelLimit(queryText) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "SELECT без LIMIT запрещен",
+			"hint":  "Добавьте LIMIT, например: ... LIMIT 100, или передайте ?allowUnbounded=true",
+		})
+		return
+	}
+
+	// 2. В режиме предпросмотра оборачиваем SELECT в LIMIT,
+	// запрашивая на одну строку больше, чтобы узнать об усечении
+	preview := c.Query("preview") == "true" && isSelectQuery(queryText)
+	buildSQL := func(q string) string {
+		if preview {
+			return fmt.Sprintf("SELECT * FROM (%s) AS preview LIMIT %d", trimStatement(q), previewRowLimit+1)
+		}
+		return q
+	}
+
+	// Для SELECT из одной таблицы добавляем первичный ключ строки в _rowKey,
+	// чтобы результат можно было редактировать
+	keyedQuery, keyTable, keyColumn, hasRowKey := withRowKey(getDB(c), queryText)
+
+	// Запись, которую не отсекла проверка первого ключевого слова (WITH ... DELETE,
+	// SELECT INTO), отклонит сам Postgres
+	db := getDB(c)
+	if readOnly {
+		tx, err := beginReadOnlyTx(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer tx.Rollback()
+		db = tx
 	}
 
-	// 2. Затем выполняем запрос
+	// 3. Затем выполняем запрос, читая строки не больше MAX_RESULT_ROWS
+	maxRows := initializers.GetConfig().MaxResultRows
 	var results []map[string]interface{}
-	if err := initializers.DB.Raw(req.Query).Scan(&results).Error; err != nil {
+	var limitExceeded bool
+	var err error
+	if hasRowKey {
+		// Ошибка прерывает транзакцию, поэтому запрос с _rowKey выполняем после точки сохранения
+		if readOnly {
+			db.SavePoint("row_key")
+		}
+		results, limitExceeded, err = scanRowsLimited(db, maxRows, buildSQL(keyedQuery), args...)
+		if err != nil && !isQueryCanceled(err) {
+			// Переписанный запрос не выполнился — выполняем исходный без _rowKey.
+			// После отмены по statement_timeout не повторяем
+			hasRowKey = false
+			if readOnly {
+				db.RollbackTo("row_key")
+			}
+		}
+	}
+	if !hasRowKey {
+		results, limitExceeded, err = scanRowsLimited(db, maxRows, buildSQL(queryText), args...)
+	}
+	if isQueryCanceled(err) {
+		respondError(c, err)
+		return
+	}
+	if isReadOnlyViolation(err) {
+		respondError(c, readOnlyError(err))
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	if limitExceeded && initializers.GetConfig().MaxResultRowsStrict {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   "Результат запроса превышает допустимый размер",
+			"maxRows": maxRows,
+		})
+		return
+	}
+
+	truncated := limitExceeded
+	if preview && len(results) > previewRowLimit {
+		results = results[:previewRowLimit]
+		truncated = true
+	}
+
+	response := gin.H{
 		"data": results,
 		"queryInfo": gin.H{
 			"id":       query.ID,
 			"useCount": query.UseCount,
 			"lastUsed": query.LastUsed.Format(time.RFC3339),
 		},
-	})
+	}
+	if preview {
+		response["preview"] = true
+	}
+	if preview || truncated {
+		response["truncated"] = truncated
+	}
+	if limitExceeded {
+		response["maxRows"] = maxRows
+	}
+	if hasRowKey {
+		response["rowKey"] = gin.H{"table": keyTable, "column": keyColumn}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // ExportTable экспортирует таблицу в CSV
@@ -595,30 +1346,82 @@ func ExportTable(c *gin.Context) {
 	table := c.Param("table")
 
 	// Проверка существования таблицы
-	var exists bool
-	initializers.DB.Raw(`
-		SELECT EXISTS (
-			SELECT FROM information_schema.tables 
-			WHERE table_name = ?
-		)`, table).Scan(&exists)
+	if !requireTable(c, table) {
+		return
+	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Таблица не найдена"})
+	format, err := parseExportFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	columnTypes, err := getColumnTypes(getDB(c), table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	columns := make([]string, len(columnTypes))
+	types := make(map[string]string, len(columnTypes))
+	for i, col := range columnTypes {
+		columns[i] = col.ColumnName
+		types[col.ColumnName] = col.DataType
+	}
+	if err := format.checkColumns(columns); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "columns": columns})
 		return
 	}
 
+	// Сортировка из ?sort=column[:desc] или сохраненная в метаданных
+	sorting, sorted, err := resolveTableSort(c, getDB(c), table, types)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if sorted {
+		format.OrderBy = sorting.orderClause()
+	}
+
+	// Выгрузка только читает данные — как ExportTableByQuery, в транзакции только для чтения
+	tx, err := beginReadOnlyTx(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
 	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", table))
+	c.Header("Content-Disposition", attachmentDisposition(table+".csv"))
 
-	if err := exportTableToWriter(table, c.Writer); err != nil {
+	rowCount, err := exportTableToWriter(tx, table, c.Writer, format)
+	if isQueryCanceled(err) {
+		respondError(c, err)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
+
+	logTableRead(c, table, "export", rowCount)
 }
 
 // Для эндпоинта /api/queries/history
 func GetQueryHistory(c *gin.Context) {
+	page, paginate := getPagination(c)
+
+	db := getDB(c).Model(&model.SavedQuery{})
+	var total int64
+	if paginate {
+		if err := db.Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		db = db.Order("id").Offset(page.Offset()).Limit(page.PageSize)
+	}
+
 	var queries []model.SavedQuery
-	if err := initializers.DB.Find(&queries).Error; err != nil {
+	if err := db.Find(&queries).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -635,6 +1438,14 @@ func GetQueryHistory(c *gin.Context) {
 		})
 	}
 
+	if paginate {
+		if response == nil {
+			response = []gin.H{}
+		}
+		c.JSON(http.StatusOK, paginatedResponse(response, page, total))
+		return
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -648,7 +1459,7 @@ func DeleteQuery(c *gin.Context) {
 		return
 	}
 
-	if err := initializers.DB.Delete(&model.SavedQuery{}, id).Error; err != nil {
+	if err := getDB(c).Delete(&model.SavedQuery{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -656,10 +1467,13 @@ func DeleteQuery(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
-// ExportQueryResults экспортирует результаты запроса
+// ExportQueryResults экспортирует результаты запроса. Запрос должен быть одиночным SELECT;
+// выполняется в транзакции только для чтения, поэтому запись (WITH ... DELETE, SELECT INTO)
+// отклоняет Postgres
 func ExportQueryResults(c *gin.Context) {
 	var req struct {
-		Query string `json:"query" binding:"required"`
+		Query    string `json:"query" binding:"required"`
+		Filename string `json:"filename"` // имя скачиваемого файла, по умолчанию query_results.csv
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -667,47 +1481,73 @@ func ExportQueryResults(c *gin.Context) {
 		return
 	}
 
-	// Проверка запроса
-	if strings.Contains(strings.ToUpper(req.Query), "DROP") ||
-		strings.Contains(strings.ToUpper(req.Query), "DELETE") {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Запрещенный запрос"})
+	if !isSelectQuery(req.Query) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Для выгрузки нужен одиночный SELECT",
+			"kind":  statementKind(req.Query),
+		})
 		return
 	}
 
-	var results []map[string]interface{}
-	if err := initializers.DB.Raw(req.Query).Scan(&results).Error; err != nil {
+	format, err := parseExportFormat(c)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", "attachment; filename=query_results.csv")
-
-	writer := csv.NewWriter(c.Writer)
-	defer writer.Flush()
+	tx, err := beginReadOnlyTx(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
 
-	if len(results) == 0 {
-		writer.Write([]string{"Нет данных"})
+	// Читаем не больше maxRows строк; остальные не выбираются из курсора
+	columns, results, truncated, err := scanRowsWithColumns(tx, format.MaxRows, req.Query)
+	if isQueryCanceled(err) {
+		respondError(c, err)
+		return
+	}
+	if isReadOnlyViolation(err) {
+		respondError(c, newAPIError(http.StatusForbidden, "Выгрузка не может изменять данные", err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Заголовки
-	headers := make([]string, 0, len(results[0]))
-	for k := range results[0] {
-		headers = append(headers, k)
+	// Заголовки в порядке колонок SELECT
+	var headers []string
+	if len(results) > 0 {
+		if err := format.checkColumns(columns); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "columns": columns})
+			return
+		}
+		headers = format.selectColumns(columns)
 	}
-	writer.Write(headers)
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", attachmentDisposition(exportFilename(req.Filename, "query_results.csv")))
+	if truncated {
+		format.markTruncated(c.Writer)
+	}
+
+	writer := format.Dialect.newWriter(c.Writer)
+	defer writer.Flush()
+
+	if len(results) == 0 {
+		writer.Write([]string{"Нет данных"})
+		return
+	}
+
+	writer.Write(format.headers(headers))
 
 	// Данные
 	for _, row := range results {
 		values := make([]string, 0, len(headers))
 		for _, h := range headers {
-			val := row[h]
-			if val == nil {
-				values = append(values, "")
-			} else {
-				values = append(values, fmt.Sprintf("%v", val))
-			}
+			values = append(values, format.formatValue(row[h], ""))
 		}
 		writer.Write(values)
 	}
@@ -717,24 +1557,31 @@ func ExportQueryResults(c *gin.Context) {
 func BackupTable(c *gin.Context) {
 	tableName := c.Param("name")
 
-	// Создаем временный файл
-	backupFile := fmt.Sprintf("backup_%s_%s.csv", tableName, time.Now().Format("20060102_150405"))
-	file, err := os.Create(backupFile)
+	if !requireTable(c, tableName) {
+		return
+	}
+
+	// Создаем временный файл; имя таблицы в путь не подставляем, в нем могут быть любые символы
+	file, err := os.CreateTemp("", "backup-*.csv")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось создать файл бэкапа"})
 		return
 	}
+	backupFile := file.Name()
 	defer os.Remove(backupFile)
 	defer file.Close()
 
 	// Экспортируем данные
-	if err := exportTableToWriter(tableName, file); err != nil {
+	rowCount, err := exportTableToWriter(getDB(c), tableName, file, exportFormat{})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Возвращаем файл
-	c.FileAttachment(backupFile, fmt.Sprintf("%s_backup.csv", tableName))
+	logTableRead(c, tableName, "backup", rowCount)
+
+	// Возвращаем файл; поддерживается докачка через Range
+	serveDownload(c, backupFile, fmt.Sprintf("%s_backup.csv", tableName))
 }
 
 // BackupRow создает резервную копию строки
@@ -743,16 +1590,21 @@ func BackupRow(c *gin.Context) {
 	rowID := c.Param("id")
 
 	// 1. Получаем имя первичного ключа для таблицы
-	pkColumn, err := getPrimaryKeyColumn(initializers.DB, tableName)
+	pkColumn, err := getPrimaryKeyColumn(getDB(c), tableName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPrimaryKeyError(c, err)
+		return
+	}
+
+	if err := validateRowID(getDB(c), tableName, pkColumn, rowID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 2. Выполняем запрос с динамическим PK
 	var data map[string]interface{}
-	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = ? LIMIT 1", tableName, pkColumn)
-	if err := initializers.DB.Raw(query, rowID).Scan(&data).Error; err != nil {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = ? LIMIT 1", quoteIdentifier(tableName), quoteIdentifier(pkColumn))
+	if err := getDB(c).Raw(query, rowID).Scan(&data).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Строка не найдена"})
 		return
 	}
@@ -782,29 +1634,112 @@ func RestoreRow(c *gin.Context) {
 		return
 	}
 
+	if !initializers.TableAllowed(backup.Table) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Доступ к таблице запрещен", "table": backup.Table})
+		return
+	}
+
 	// Проверяем существование таблицы
-	var exists bool
-	if err := initializers.DB.Raw(`
-        SELECT EXISTS (
-            SELECT FROM information_schema.tables 
-            WHERE table_name = ?
-        )`, backup.Table).Scan(&exists).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !requireTable(c, backup.Table) {
 		return
 	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Таблица не найдена"})
+	if backup.ID == "" || len(backup.Data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нужно указать id и data"})
+		return
+	}
+
+	// Строка ищется по первичному ключу таблицы, а не по колонке id
+	pkColumn, err := getPrimaryKeyColumn(getDB(c), backup.Table)
+	if err != nil {
+		respondPrimaryKeyError(c, err)
+		return
+	}
+	if err := validateRowID(getDB(c), backup.Table, pkColumn, backup.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Ключ в data должен совпадать с id, иначе восстановление перезаписало бы ключ другой строкой
+	if val, ok := backup.Data[pkColumn]; ok && val != nil && fmt.Sprint(val) != backup.ID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Первичный ключ в data не совпадает с id",
+			"column": pkColumn,
+			"id":     backup.ID,
+			"data":   val,
+		})
 		return
 	}
 
-	// Восстанавливаем данные
-	if err := initializers.DB.Table(backup.Table).Where("id = ?", backup.ID).Updates(backup.Data).Error; err != nil {
+	columnTypes, err := getColumnTypes(getDB(c), backup.Table)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	types := make(map[string]string, len(columnTypes))
+	columnNames := make([]string, 0, len(columnTypes))
+	for _, col := range columnTypes {
+		types[col.ColumnName] = col.DataType
+		columnNames = append(columnNames, col.ColumnName)
+	}
+
+	var unknown []string
+	updates := make(map[string]interface{}, len(backup.Data))
+	for field, val := range backup.Data {
+		dataType, ok := types[field]
+		if !ok {
+			unknown = append(unknown, field)
+			continue
+		}
+		if isJSONType(dataType) {
+			encoded, err := coerceInput(val, dataType)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "column": field})
+				return
+			}
+			val = encoded
+		}
+		updates[field] = val
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Неизвестные колонки",
+			"unknown": unknown,
+			"columns": columnNames,
+		})
+		return
+	}
+
+	// Восстанавливаем данные и читаем строку заново, чтобы вернуть то, что записано в БД
+	var restored map[string]interface{}
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		result := tx.Table(backup.Table).Where(quoteIdentifier(pkColumn)+" = ?", backup.ID).Updates(updates)
+		if result.Error != nil {
+			return dbWriteError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return newAPIError(http.StatusNotFound, "Строка не найдена", nil)
+		}
+
+		selectSQL := fmt.Sprintf("SELECT * FROM %s WHERE %s = ?", quoteIdentifier(backup.Table), quoteIdentifier(pkColumn))
+		return tx.Raw(selectSQL, backup.ID).Scan(&restored).Error
+	})
+	if !ok {
+		return
+	}
+
+	for name, val := range restored {
+		restored[name] = coerceValue(val, types[name])
+	}
+	logTableChange(getDB(c), c, backup.Table, "update", backup.ID, backup.Data)
 
-	c.JSON(http.StatusOK, gin.H{"status": "Строка восстановлена"})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "Строка восстановлена",
+		"table":  backup.Table,
+		"id":     backup.ID,
+		"data":   restored,
+	})
 }
 
 // AddColumn добавляет колонку в таблицу
@@ -820,72 +1755,161 @@ func AddColumn(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.Name = initializers.NormalizeIdentifier(req.Name)
 
-	// Проверяем существование таблицы
-	var exists bool
-	if err := initializers.DB.Raw(`
-        SELECT EXISTS (
-            SELECT FROM information_schema.tables 
-            WHERE table_name = ?
-        )`, tableName).Scan(&exists).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !isValidIdentifier(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Некорректное имя колонки",
+			"name":  req.Name,
+		})
 		return
 	}
 
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Таблица не найдена"})
+	if err := checkIdentifierLength(req.Name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Слишком длинное имя колонки", "details": err.Error()})
+		return
+	}
+
+	// Тип подставляется в ALTER TABLE как есть, поэтому допускаются только типы из каталога
+	if !validColumnTypes[req.Type] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Недопустимый тип данных",
+			"type":    req.Type,
+			"allowed": getKeys(validColumnTypes),
+		})
+		return
+	}
+
+	var reserved []string
+	if isReservedWord(req.Name) {
+		if c.Query("strict") == "true" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    "Имя совпадает с зарезервированным словом SQL",
+				"reserved": []string{req.Name},
+			})
+			return
+		}
+		reserved = append(reserved, req.Name)
+	}
+
+	// Проверяем существование таблицы
+	if !requireTable(c, tableName) {
 		return
 	}
 
 	// Проверяем, что колонка не существует
-	var columnExists bool
-	if err := initializers.DB.Raw(`
-        SELECT EXISTS (
-            SELECT FROM information_schema.columns 
-            WHERE table_name = ? AND column_name = ?
-        )`, tableName, req.Name).Scan(&columnExists).Error; err != nil {
+	exists, err := columnExists(getDB(c), tableName, req.Name)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if columnExists {
-		c.JSON(http.StatusConflict, gin.H{"error": "Колонка уже существует"})
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "Колонка уже существует", "name": req.Name})
 		return
 	}
 
 	// Добавляем колонку
-	sql := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", tableName, req.Name, req.Type)
-	if err := initializers.DB.Exec(sql).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	sql := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", quoteIdentifier(tableName), quoteIdentifier(req.Name), req.Type)
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+		if err := tx.Exec(sql).Error; err != nil {
+			return newAPIError(http.StatusInternalServerError, err.Error(), nil)
+		}
+		return nil
+	})
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "Колонка добавлена"})
+	response := gin.H{"status": "Колонка добавлена"}
+	if len(reserved) > 0 {
+		response["warning"] = "Использовано зарезервированное слово SQL, оно экранировано кавычками"
+		response["reserved"] = reserved
+	}
+	c.JSON(http.StatusOK, withDebugSQL(c, response, sql))
 }
 
 // Получение данных таблицы
 func GetTableData(c *gin.Context) {
 	tableName := c.Param("name")
+	if !requireTable(c, tableName) {
+		return
+	}
 
-	// Получаем колонки
-	var columns []string
-	if err := initializers.DB.Raw(`
-        SELECT column_name 
-        FROM information_schema.columns 
-        WHERE table_name = ?
-        ORDER BY ordinal_position
-    `, tableName).Scan(&columns).Error; err != nil {
+	// Получаем колонки вместе с типами
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	columns := make([]string, 0, len(columnTypes))
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		columns = append(columns, col.ColumnName)
+		types[col.ColumnName] = col.DataType
+	}
+	columns = applyColumnOrder(columns, getColumnOrder(getDB(c), tableName))
+
+	db := getDB(c).Table(tableName)
+
+	// Проекция ?fields=a,b,c: только перечисленные колонки, первичный ключ — всегда
+	if raw, ok := c.GetQuery("fields"); ok {
+		selected, apiErr := parseFieldsParam(raw, func(name string) (string, bool) {
+			_, ok := types[name]
+			return name, ok
+		})
+		if apiErr != nil {
+			apiErr.Body["fields"] = columns
+			respondError(c, apiErr)
+			return
+		}
+		pkColumns, err := getPrimaryKeyColumns(getDB(c), tableName)
+		if err != nil && !errors.Is(err, errNoPrimaryKey) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		columns = projectColumns(columns, selected, pkColumns)
+		db = db.Select(selectClause(columns))
+	}
+
+	// Применяем фильтры ?filter=column:op:value (можно указать несколько)
+	for _, raw := range c.QueryArray("filter") {
+		filter, err := parseFilter(raw, types)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		db = db.Where(filter.Expr, filter.Args...)
+	}
+
+	// Сортировка из ?sort=column[:desc] или сохраненная в метаданных
+	sorting, sorted, err := resolveTableSort(c, getDB(c), tableName, types)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if sorted {
+		db = db.Order(sorting.orderClause())
+	}
+
 	// Получаем данные
 	var rows []map[string]interface{}
-	if err := initializers.DB.Table(tableName).Find(&rows).Error; err != nil {
+	if err := db.Find(&rows).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Приводим значения к JSON-типам по типу колонки; NULL — явный null у каждой колонки
+	for _, row := range rows {
+		normalizeRow(row, columns, types)
+	}
+
+	logTableRead(c, tableName, "read", len(rows))
+
 	c.JSON(http.StatusOK, gin.H{
 		"columns": columns,
 		"rows":    rows,
@@ -902,14 +1926,87 @@ func AddRow(c *gin.Context) {
 		return
 	}
 
-	if err := initializers.DB.Table(tableName).Create(&rowData).Error; err != nil {
+	// Проверяем ключи по схеме до вставки, чтобы вернуть 400 вместо ошибки Postgres
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if len(columnTypes) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+
+	columnNames := make([]string, 0, len(columnTypes))
+	for _, col := range columnTypes {
+		columnNames = append(columnNames, col.ColumnName)
+	}
+	if apiErr := unknownColumnsError(rowData, columnNames); apiErr != nil {
+		respondError(c, apiErr)
+		return
+	}
+
+	if errs := validateFields(tableName, rowData); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные значения", "fields": errs})
+		return
+	}
+
+	// Значение автоинкрементного ключа задается только с ?allowSerial=true
+	if c.Query("allowSerial") != "true" {
+		serialColumns, err := getSerialColumns(getDB(c), tableName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for field := range rowData {
+			if serialColumns[field] {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":  "Колонка заполняется автоматически, используйте ?allowSerial=true",
+					"column": field,
+				})
+				return
+			}
+		}
+	}
+
+	// Вложенные объекты и массивы для JSON-колонок сериализуем сами:
+	// GORM не умеет передавать map и slice как значение колонки
+	insertData := make(map[string]interface{}, len(rowData))
+	for _, col := range columnTypes {
+		val, ok := rowData[col.ColumnName]
+		if !ok {
+			continue
+		}
+		if isJSONType(col.DataType) {
+			encoded, err := coerceInput(val, col.DataType)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "column": col.ColumnName})
+				return
+			}
+			val = encoded
+		}
+		insertData[col.ColumnName] = val
+	}
+
+	if err := getDB(c).Table(tableName).Create(&insertData).Error; err != nil {
+		respondError(c, dbWriteError(err))
+		return
+	}
+
+	// В ответе возвращаем JSON-значения в исходном виде, а не строкой
+	for field, val := range rowData {
+		insertData[field] = val
+	}
+
+	var rowID string
+	if pkColumn, err := getPrimaryKeyColumn(getDB(c), tableName); err == nil && insertData[pkColumn] != nil {
+		rowID = fmt.Sprint(insertData[pkColumn])
+	}
+	logTableChange(getDB(c), c, tableName, "insert", rowID, insertData)
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "Строка добавлена",
-		"data":   rowData,
+		"data":   insertData,
 	})
 }
 
@@ -925,20 +2022,98 @@ func UpdateRow(c *gin.Context) {
 	}
 
 	// Получаем имя первичного ключа
-	pkColumn, err := getPrimaryKeyColumn(initializers.DB, tableName)
+	pkColumn, err := getPrimaryKeyColumn(getDB(c), tableName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPrimaryKeyError(c, err)
+		return
+	}
+
+	if err := validateRowID(getDB(c), tableName, pkColumn, rowID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := initializers.DB.Table(tableName).Where(pkColumn+" = ?", rowID).Updates(rowData).Error; err != nil {
+	// Проверяем ключи по схеме до обновления, как в AddRow: 400 вместо ошибки Postgres
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	columns := make([]string, 0, len(columnTypes))
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		columns = append(columns, col.ColumnName)
+		types[col.ColumnName] = col.DataType
+	}
+	if apiErr := unknownColumnsError(rowData, columns); apiErr != nil {
+		respondError(c, apiErr)
+		return
+	}
+
+	if errs := validateFields(tableName, rowData); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные значения", "fields": errs})
+		return
+	}
+
+	// Вложенные объекты и массивы для JSON-колонок сериализуем сами, как в AddRow
+	updateData := make(map[string]interface{}, len(rowData))
+	for field, val := range rowData {
+		if isJSONType(types[field]) {
+			encoded, err := coerceInput(val, types[field])
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "column": field})
+				return
+			}
+			val = encoded
+		}
+		updateData[field] = val
+	}
+
+	// Сравниваем строку до и после обновления: триггеры и значения по умолчанию
+	// могут изменить колонки, которых не было в запросе
+	selectSQL := fmt.Sprintf("SELECT * FROM %s WHERE %s = ?", quoteIdentifier(tableName), quoteIdentifier(pkColumn))
+	var before, after map[string]interface{}
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := tx.Raw(selectSQL+" FOR UPDATE", rowID).Scan(&before).Error; err != nil {
+			return err
+		}
+		if len(before) == 0 {
+			return newAPIError(http.StatusNotFound, "Строка не найдена", nil)
+		}
+
+		if err := tx.Table(tableName).Where(quoteIdentifier(pkColumn)+" = ?", rowID).Updates(updateData).Error; err != nil {
+			return dbWriteError(err)
+		}
+
+		return tx.Raw(selectSQL, rowID).Scan(&after).Error
+	})
+	if !ok {
+		return
+	}
+
+	// Обе версии приводим к JSON-типам, как в GetRow: иначе JSON-колонки сравнивались бы
+	// и возвращались строкой, а время — в формате драйвера
+	normalizeRow(before, columns, types)
+	normalizeRow(after, columns, types)
+
+	changed := []string{}
+	changes := map[string]interface{}{}
+	for column, val := range after {
+		if !reflect.DeepEqual(before[column], val) {
+			changed = append(changed, column)
+			changes[column] = val
+		}
+	}
+	sort.Strings(changed)
+
+	if len(changed) > 0 {
+		logTableChange(getDB(c), c, tableName, "update", rowID, changes)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": "Строка обновлена",
-		"data":   rowData,
+		"status":  "Строка обновлена",
+		"data":    after,
+		"changed": changed,
 	})
 }
 
@@ -948,20 +2123,104 @@ func DeleteRow(c *gin.Context) {
 	rowID := c.Param("id")
 
 	// Получаем имя первичного ключа
-	pkColumn, err := getPrimaryKeyColumn(initializers.DB, tableName)
+	pkColumn, err := getPrimaryKeyColumn(getDB(c), tableName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPrimaryKeyError(c, err)
 		return
 	}
 
-	if err := initializers.DB.Table(tableName).Where(pkColumn+" = ?", rowID).Delete(nil).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := validateRowID(getDB(c), tableName, pkColumn, rowID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// С ?cascade=true вместе со строкой удаляются ссылающиеся на нее строки других таблиц
+	if c.Query("cascade") == "true" {
+		deleted := map[string]int64{}
+		ok := WithTransaction(c, func(tx *gorm.DB) error {
+			// Каскад не должен удалять строки таблиц, к которым нет доступа
+			dependents, err := cascadeDependents(tx, tableName)
+			if err != nil {
+				return err
+			}
+			if forbidden := forbiddenTables(dependents); len(forbidden) > 0 {
+				apiErr := newAPIError(http.StatusForbidden, "Каскадное удаление затрагивает таблицы, доступ к которым запрещен", nil)
+				apiErr.Body["tables"] = forbidden
+				return apiErr
+			}
+			if err := deleteCascade(tx, tableName, quoteIdentifier(pkColumn)+" = ?", []interface{}{rowID}, 0, deleted); err != nil {
+				return dbWriteError(err)
+			}
+			return nil
+		})
+		if !ok {
+			return
+		}
+
+		logTableChange(getDB(c), c, tableName, "delete", rowID, nil)
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "Строка удалена",
+			"deleted": deleted,
+		})
+		return
+	}
+
+	if err := getDB(c).Table(tableName).Where(quoteIdentifier(pkColumn)+" = ?", rowID).Delete(nil).Error; err != nil {
+		apiErr := dbWriteError(err)
+		if apiErr.Status == http.StatusConflict {
+			apiErr.Body["hint"] = "Удалите зависимые строки или используйте ?cascade=true"
+		}
+		respondError(c, apiErr)
 		return
 	}
 
+	logTableChange(getDB(c), c, tableName, "delete", rowID, nil)
 	c.JSON(http.StatusOK, gin.H{"status": "Строка удалена"})
 }
 
+// DeleteAllRows удаляет все строки таблицы через DELETE, а не TRUNCATE:
+// срабатывают триггеры и проверки внешних ключей. Требует явного ?all=true
+func DeleteAllRows(c *gin.Context) {
+	tableName := c.Param("name")
+
+	if c.Query("all") != "true" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Для удаления всех строк укажите ?all=true"})
+		return
+	}
+
+	if !requireTable(c, tableName) {
+		return
+	}
+
+	var deleted int64
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		result := tx.Exec(fmt.Sprintf("DELETE FROM %s", quoteIdentifier(tableName)))
+		if isQueryCanceled(result.Error) {
+			return result.Error
+		}
+		if result.Error != nil {
+			// На строки ссылаются другие таблицы — 409 с именем внешнего ключа
+			apiErr := dbWriteError(result.Error)
+			if apiErr.Status == http.StatusConflict {
+				apiErr.Body["hint"] = "Сначала удалите строки таблиц, ссылающихся на эту"
+			}
+			return apiErr
+		}
+		deleted = result.RowsAffected
+
+		logTableChange(tx, c, tableName, "delete_all", "", map[string]interface{}{"deleted": deleted})
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "Строки удалены",
+		"deleted": deleted,
+	})
+}
+
 // Вспомогательная функция для получения первичного ключа
 //func getPrimaryKeyColumn(db *gorm.DB, tableName string) (string, error) {
 //	var pkColumn string
@@ -985,72 +2244,281 @@ func DropColumn(c *gin.Context) {
 	tableName := c.Param("name")
 	columnName := c.Param("column")
 
-	sql := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", tableName, columnName)
-	if err := initializers.DB.Exec(sql).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !isValidIdentifier(tableName) || !isValidIdentifier(columnName) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректное имя таблицы или колонки"})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"status": "Колонка удалена"})
-}
-
-// Вспомогательные функции
-
-func exportTableToWriter(table string, w io.Writer) error {
-	var results []map[string]interface{}
-	if err := initializers.DB.Table(table).Find(&results).Error; err != nil {
-		return err
+	if !requireTable(c, tableName) {
+		return
 	}
 
-	writer := csv.NewWriter(w)
-	defer writer.Flush()
-
-	if len(results) == 0 {
+	sql := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", quoteIdentifier(tableName), quoteIdentifier(columnName))
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+		exists, err := columnExists(tx, tableName, columnName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			apiErr := newAPIError(http.StatusNotFound, "Колонка не найдена", nil)
+			apiErr.Body["column"] = columnName
+			return apiErr
+		}
+		if err := tx.Exec(sql).Error; err != nil {
+			return newAPIError(http.StatusBadRequest, "Ошибка удаления колонки", err)
+		}
 		return nil
+	})
+	if !ok {
+		return
 	}
 
-	// Заголовки
-	headers := make([]string, 0, len(results[0]))
-	for k := range results[0] {
-		headers = append(headers, k)
-	}
-	if err := writer.Write(headers); err != nil {
-		return err
-	}
+	c.JSON(http.StatusOK, withDebugSQL(c, gin.H{"status": "Колонка удалена"}, sql))
+}
 
-	// Данные
-	for _, row := range results {
-		values := make([]string, 0, len(headers))
-		for _, h := range headers {
-			val := row[h]
-			strVal := ""
-
-			switch v := val.(type) {
-			case nil:
-				strVal = ""
-			case []byte:
-				strVal = string(v)
-			case time.Time:
-				strVal = v.Format(time.RFC3339)
-			default:
-				strVal = fmt.Sprintf("%v", v)
+// UpdateColumnOptions изменяет значение по умолчанию и допустимость NULL для колонки
+func UpdateColumnOptions(c *gin.Context) {
+	tableName := c.Param("name")
+	columnName := c.Param("column")
+
+	if !isValidIdentifier(tableName) || !isValidIdentifier(columnName) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректное имя таблицы или колонки"})
+		return
+	}
+
+	// Разбираем тело как набор полей, чтобы отличать "default": null от отсутствия поля
+	var req map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rawDefault, hasDefault := req["default"]
+	rawNullable, hasNullable := req["nullable"]
+	if !hasDefault && !hasNullable {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нужно указать default и/или nullable"})
+		return
+	}
+
+	// Значение по умолчанию: null — удалить, строка — литерал, число/bool — как есть
+	var defaultSQL *string
+	if hasDefault {
+		var err error
+		if defaultSQL, err = parseDefaultLiteral(rawDefault); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var nullable bool
+	if hasNullable {
+		if err := json.Unmarshal(rawNullable, &nullable); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "nullable должен быть true или false"})
+			return
+		}
+	}
+
+	table := quoteIdentifier(tableName)
+	column := quoteIdentifier(columnName)
+
+	var statements []string
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+
+		// Проверяем, что колонка существует
+		exists, err := columnExists(tx, tableName, columnName)
+		if err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка проверки колонки", err)
+		}
+		if !exists {
+			return newAPIError(http.StatusNotFound, "Колонка не найдена", nil)
+		}
+
+		if hasDefault {
+			if defaultSQL != nil {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", table, column, *defaultSQL))
+			} else {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, column))
+			}
+		}
+
+		if hasNullable {
+			if nullable {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", table, column))
+			} else {
+				// Перед SET NOT NULL проверяем, что в колонке нет NULL
+				var nullCount int64
+				if err := tx.Raw(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IS NULL", table, column)).Scan(&nullCount).Error; err != nil {
+					return err
+				}
+				if nullCount > 0 {
+					apiErr := newAPIError(http.StatusConflict, "В колонке есть NULL значения", nil)
+					apiErr.Body["nullCount"] = nullCount
+					return apiErr
+				}
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", table, column))
 			}
+		}
 
-			// Экранируем кавычки для CSV
-			strVal = strings.ReplaceAll(strVal, `"`, `""`)
-			if strings.ContainsAny(strVal, `,"`) {
-				strVal = `"` + strVal + `"`
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				apiErr := newAPIError(http.StatusInternalServerError, "Ошибка выполнения SQL", err)
+				apiErr.Body["sql"] = stmt
+				return apiErr
 			}
+		}
+
+		// Обновляем метаданные, если таблица создана через API
+		if err := updateTableMeta(tx, tableName, func(meta *model.TableMeta) {
+			updateColumnOptions(meta, columnName, func(opt *model.ColumnOptions) {
+				if hasDefault {
+					opt.Default = defaultSQL
+				}
+				if hasNullable {
+					opt.Nullable = &nullable
+				}
+			})
+		}); err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка сохранения метаданных", err)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "Колонка изменена",
+		"statements": statements,
+	})
+}
+
+// SetColumnOrder сохраняет порядок отображения колонок в метаданных.
+// Физическая структура таблицы не меняется
+func SetColumnOrder(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var req struct {
+		Columns []string `json:"columns" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var meta model.TableMeta
+	if err := getDB(c).Where("name = ?", tableName).First(&meta).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Метаданные таблицы не найдены"})
+		return
+	}
+
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing := make(map[string]bool, len(columnTypes))
+	var existingNames []string
+	for _, col := range columnTypes {
+		existing[col.ColumnName] = true
+		existingNames = append(existingNames, col.ColumnName)
+	}
+
+	// Порядок должен содержать ровно существующие колонки, каждую по одному разу
+	seen := make(map[string]bool, len(req.Columns))
+	for _, col := range req.Columns {
+		if !existing[col] || seen[col] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    "Порядок должен содержать каждую колонку таблицы ровно один раз",
+				"column":   col,
+				"expected": existingNames,
+			})
+			return
+		}
+		seen[col] = true
+	}
+	if len(seen) != len(existing) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Порядок должен содержать каждую колонку таблицы ровно один раз",
+			"expected": existingNames,
+		})
+		return
+	}
+
+	orderJSON, _ := json.Marshal(req.Columns)
+	meta.ColumnOrder = string(orderJSON)
+	if err := getDB(c).Save(&meta).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения метаданных"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "Порядок колонок сохранен",
+		"columns": req.Columns,
+	})
+}
+
+// Вспомогательные функции
+
+// exportTableToWriter пишет таблицу в CSV и возвращает количество выгруженных строк
+func exportTableToWriter(db *gorm.DB, table string, w io.Writer, format exportFormat) (int, error) {
+	query := fmt.Sprintf("SELECT * FROM %s", quoteIdentifier(table))
+	if format.Where != "" {
+		query += " WHERE " + format.Where
+	}
+	if format.OrderBy != "" {
+		query += " ORDER BY " + format.OrderBy
+	}
+	results, truncated, err := scanRowsLimited(db, format.MaxRows, query, format.WhereArgs...)
+	if err != nil {
+		return 0, err
+	}
+	if truncated {
+		format.markTruncated(w)
+	}
 
-			values = append(values, strVal)
+	writer := format.Dialect.newWriter(w)
+	defer writer.Flush()
+
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	// Заголовки в порядке колонок таблицы с учетом порядка из метаданных
+	columnTypes, err := getColumnTypes(db, table)
+	if err != nil {
+		return 0, err
+	}
+	headers := make([]string, 0, len(columnTypes))
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		headers = append(headers, col.ColumnName)
+		types[col.ColumnName] = col.DataType
+	}
+	headers = format.selectColumns(applyColumnOrder(headers, getColumnOrder(db, table)))
+	if err := writer.Write(format.headers(headers)); err != nil {
+		return 0, err
+	}
+
+	// Данные
+	for _, row := range results {
+		values := make([]string, 0, len(headers))
+		for _, h := range headers {
+			// Кавычки и разделители экранирует csv.Writer
+			values = append(values, format.formatValue(row[h], types[h]))
 		}
 
 		if err := writer.Write(values); err != nil {
-			return err
+			return 0, err
 		}
 	}
 
-	return nil
+	return len(results), nil
 }
 
 // RestoreTable восстанавливает таблицу из CSV файла
@@ -1058,18 +2526,7 @@ func RestoreTable(c *gin.Context) {
 	tableName := c.Param("name")
 
 	// 1. Проверяем существование таблицы
-	var exists bool
-	if err := initializers.DB.Raw(`
-        SELECT EXISTS (
-            SELECT FROM information_schema.tables 
-            WHERE table_name = ?
-        )`, tableName).Scan(&exists).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки таблицы"})
-		return
-	}
-
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Таблица '%s' не найдена", tableName)})
+	if !requireTable(c, tableName) {
 		return
 	}
 
@@ -1088,118 +2545,572 @@ func RestoreTable(c *gin.Context) {
 	}
 	defer f.Close()
 
-	// 4. Читаем CSV
-	reader := csv.NewReader(f)
-	headers, err := reader.Read()
+	data, err := io.ReadAll(f)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения CSV"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения файла"})
+		return
+	}
+	if err := checkCSVData(data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный файл", "details": err.Error()})
+		return
+	}
+
+	dialect, err := parseCSVDialect(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 5. Начинаем транзакцию
-	tx := initializers.DB.Begin()
+	// 4. Читаем CSV. Колонки берутся из ?columns=a,b,c, из первой строки файла или,
+	// при ?header=false без columns, по порядку колонок таблицы
+	reader := dialect.newReader(data)
+	reader.FieldsPerRecord = -1 // число значений проверяем сами, с номером строки
+	hasHeader := c.Query("header") != "false"
 
-	// 6. Очищаем таблицу перед восстановлением
-	if err := tx.Exec(fmt.Sprintf("TRUNCATE TABLE %s", tableName)).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка очистки таблицы"})
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	tableColumns := make([]string, 0, len(columnTypes))
+	known := make(map[string]bool, len(columnTypes))
+	for _, col := range columnTypes {
+		tableColumns = append(tableColumns, col.ColumnName)
+		known[col.ColumnName] = true
+	}
 
-	// 7. Импортируем данные
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
+	var headers []string
+	positional := false
+	if raw := c.Query("columns"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			headers = append(headers, strings.TrimSpace(name))
 		}
+	} else if !hasHeader {
+		headers = tableColumns
+		positional = true
+	}
+
+	if hasHeader {
+		first, err := reader.Read()
 		if err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения строки CSV"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения CSV"})
 			return
 		}
+		if headers == nil {
+			headers = first
+		}
+	}
 
-		// Формируем запрос
-		values := make([]string, len(record))
-		for i, v := range record {
-			if v == "NULL" {
-				values[i] = "NULL"
-			} else {
-				values[i] = fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", "''"))
+	// С ?columnMap=csvCol:dbCol или ?partial=true колонки CSV сопоставляются с колонками
+	// таблицы по карте и по имени, лишние колонки CSV пропускаются
+	columnMap, err := parseColumnMap(c.Query("columnMap"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	partial := c.Query("partial") == "true" || columnMap != nil
+	if columnMap != nil && positional {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "columnMap требует заголовок CSV или ?columns="})
+		return
+	}
+
+	mapping, err := mapRestoreColumns(headers, columnMap, known, partial)
+	if err != nil {
+		response := gin.H{"error": err.Error(), "columns": tableColumns}
+		if !partial {
+			response["hint"] = "Используйте ?partial=true или ?columnMap=csvCol:dbCol, чтобы пропустить лишние колонки"
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	quotedHeaders := make([]string, len(mapping.Targets))
+	for i, name := range mapping.Targets {
+		quotedHeaders[i] = quoteIdentifier(name)
+	}
+
+	dataOnly := c.Query("dataOnly") == "true"
+	respondRestored := func(rows int64) {
+		response := gin.H{
+			"status": fmt.Sprintf("Таблица %s успешно восстановлена", tableName),
+			"rows":   rows,
+		}
+		if partial {
+			response["ignored"] = mapping.Ignored
+			response["defaulted"] = mapping.defaulted(tableColumns)
+		}
+		c.JSON(http.StatusOK, response)
+	}
+
+	// Быстрый путь: COPY FROM STDIN в транзакции на выделенном подключении pgx.
+	// При сопоставлении по порядку строки могут быть короче списка колонок, а COPY не умеет
+	// пропускать колонки файла, поэтому в этих случаях, как и при ?copy=false или
+	// ?lazyQuotes=true, используется построчная вставка
+	var copying *copyTx
+	copySQL, copyOK := copyCSVStatement(tableName, mapping.Targets, hasHeader, dialect)
+	if copyOK && !positional && len(mapping.Ignored) == 0 && c.Query("copy") != "false" {
+		copying, err = beginCopyTx(c)
+		if err != nil && !errors.Is(err, errCopyUnsupported) {
+			respondError(c, newAPIError(http.StatusInternalServerError, "Ошибка начала транзакции", err))
+			return
+		}
+	}
+	var tx *gorm.DB
+	if copying != nil {
+		defer copying.close()
+		tx = copying.DB
+	} else {
+		tx = beginTx(c)
+	}
+
+	// 5. Восстанавливаем в транзакции: с блокировкой схемы и statement_timeout запроса
+	// в обоих путях
+	var rowCount int64
+	logRestore := func(tx *gorm.DB) {
+		logTableChange(tx, c, tableName, "restore", "", map[string]interface{}{
+			"rows":      rowCount,
+			"truncated": !dataOnly,
+		})
+	}
+	ok := runTransaction(c, tx, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+
+		// 6. Очищаем таблицу перед восстановлением; с ?dataOnly=true строки только добавляются
+		if !dataOnly {
+			if err := tx.Exec(fmt.Sprintf("TRUNCATE TABLE %s", quoteIdentifier(tableName))).Error; err != nil {
+				return newAPIError(http.StatusInternalServerError, "Ошибка очистки таблицы", err)
+			}
+		}
+
+		if copying != nil {
+			n, err := copying.copyFrom(copySQL, bytes.NewReader(data))
+			if err != nil {
+				return newAPIError(http.StatusBadRequest, "Ошибка загрузки CSV", err)
 			}
+			rowCount = n
+			logRestore(tx)
+			return nil
 		}
 
-		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-			tableName,
-			strings.Join(headers, ", "),
-			strings.Join(values, ", "))
+		// 7. Импортируем данные
+		for line := 1; ; line++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return newAPIError(http.StatusBadRequest, "Ошибка чтения строки CSV", err)
+			}
 
-		if err := tx.Exec(query).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Ошибка вставки данных",
-				"details": err.Error(),
+			// При сопоставлении по порядку короткие строки заполняют первые колонки таблицы
+			columns := quotedHeaders
+			expected := len(headers)
+			if positional && len(record) < len(columns) {
+				columns = columns[:len(record)]
+				expected = len(record)
+			}
+			if len(record) != expected {
+				apiErr := newAPIError(http.StatusBadRequest,
+					fmt.Sprintf("Количество значений (%d) не совпадает с количеством колонок (%d)", len(record), expected), nil)
+				apiErr.Body["line"] = line
+				return apiErr
+			}
+			if len(mapping.Ignored) > 0 {
+				record = mapping.pick(record)
+			}
+
+			// Формируем запрос
+			values := make([]string, len(record))
+			for i, v := range record {
+				if v == "NULL" {
+					values[i] = "NULL"
+				} else {
+					values[i] = fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", "''"))
+				}
+			}
+
+			query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				quoteIdentifier(tableName),
+				strings.Join(columns, ", "),
+				strings.Join(values, ", "))
+
+			if err := tx.Exec(query).Error; err != nil {
+				return newAPIError(http.StatusInternalServerError, "Ошибка вставки данных", err)
+			}
+			rowCount++
+		}
+		logRestore(tx)
+		return nil
+	})
+
+	// 8. Транзакция зафиксирована в runTransaction
+	if !ok {
+		return
+	}
+
+	respondRestored(rowCount)
+}
+
+// ImportTable импортирует CSV в таблицу, при необходимости создавая ее
+// (?createIfMissing=true) с типами колонок, определенными по данным.
+// Заголовки CSV проверяются как имена колонок в CreateTable, а для существующей таблицы
+// должны совпадать с ее колонками. Новая таблица получает первичный ключ: колонку id из CSV
+// или добавленную id SERIAL; при REQUIRE_PRIMARY_KEY=false колонка id из CSV ключом не становится
+func ImportTable(c *gin.Context) {
+	tableName := c.Param("name")
+	createIfMissing := c.Query("createIfMissing") == "true"
+
+	if apiErr := validateTableName(tableName); apiErr != nil {
+		respondError(c, apiErr)
+		return
+	}
+
+	dialect, err := parseCSVDialect(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 1. Читаем CSV целиком: для определения типов нужна выборка строк
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл не загружен"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка открытия файла"})
+		return
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения файла"})
+		return
+	}
+
+	reader := dialect.newReader(data)
+	headers, err := reader.Read()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения CSV"})
+		return
+	}
+
+	// 2. Заголовки — имена колонок: те же правила, что в CreateTable
+	seen := make(map[string]bool, len(headers))
+	for i, h := range headers {
+		h = initializers.NormalizeIdentifier(h)
+		headers[i] = h
+
+		if apiErr := validateColumnName(h, i+1); apiErr != nil {
+			apiErr.Body["hint"] = "Проверьте заголовок CSV"
+			respondError(c, apiErr)
+			return
+		}
+		if seen[h] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    "Дублирующееся имя колонки в заголовке CSV",
+				"position": i + 1,
+				"name":     h,
 			})
 			return
 		}
+		seen[h] = true
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения строки CSV", "details": err.Error()})
+		return
 	}
 
-	// 8. Фиксируем транзакцию
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка фиксации транзакции"})
+	// 3. Проверяем или создаем таблицу и вставляем строки в одной транзакции
+	var schema []gin.H
+	created, withoutPK := false, false
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+
+		exists, err := tableExists(tx, tableName)
+		if err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка проверки таблицы", err)
+		}
+
+		switch {
+		case exists:
+			if err := checkImportHeaders(tx, tableName, headers); err != nil {
+				return err
+			}
+		case !createIfMissing:
+			return tableNotFound(tableName)
+		default:
+			schema, withoutPK, err = createImportTable(tx, tableName, headers, records)
+			if err != nil {
+				return err
+			}
+			created = true
+		}
+
+		// 4. Импортируем данные, пустые значения и NULL вставляем как NULL
+		quoted := make([]string, len(headers))
+		placeholders := make([]string, len(headers))
+		for i, h := range headers {
+			quoted[i] = quoteIdentifier(h)
+			placeholders[i] = "?"
+		}
+		insertSQL := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			quoteIdentifier(tableName),
+			strings.Join(quoted, ", "),
+			strings.Join(placeholders, ", "))
+
+		for i, record := range records {
+			values := make([]interface{}, len(record))
+			for j, v := range record {
+				if v == "" || v == "NULL" {
+					values[j] = nil
+				} else {
+					values[j] = v
+				}
+			}
+
+			if err := tx.Exec(insertSQL, values...).Error; err != nil {
+				apiErr := newAPIError(http.StatusBadRequest, "Ошибка вставки данных", err)
+				apiErr.Body["row"] = i + 1
+				return apiErr
+			}
+		}
+		return nil
+	})
+
+	// 5. Транзакция зафиксирована в WithTransaction
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": fmt.Sprintf("Таблица %s успешно восстановлена", tableName)})
+	response := gin.H{
+		"status":   fmt.Sprintf("Импортировано строк: %d", len(records)),
+		"table":    tableName,
+		"imported": len(records),
+		"created":  created,
+	}
+	if created {
+		response["schema"] = schema
+	}
+	if withoutPK {
+		response["notice"] = noPrimaryKeyNotice
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-func restoreTableFromZip(tx *gorm.DB, zipFile *zip.File, tableName string) error {
-	rc, err := zipFile.Open()
+// checkImportHeaders проверяет, что все колонки из заголовка CSV есть в таблице
+func checkImportHeaders(tx *gorm.DB, tableName string, headers []string) error {
+	columns, err := getColumnTypes(tx, tableName)
 	if err != nil {
-		return err
+		return newAPIError(http.StatusInternalServerError, "Ошибка получения колонок таблицы", err)
 	}
-	defer rc.Close()
 
-	reader := csv.NewReader(rc)
-	headers, err := reader.Read()
+	known := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		known[col.ColumnName] = true
+	}
+
+	var unknown []string
+	for _, h := range headers {
+		if !known[h] {
+			unknown = append(unknown, h)
+		}
+	}
+	if len(unknown) > 0 {
+		apiErr := newAPIError(http.StatusBadRequest, "Колонки из заголовка CSV отсутствуют в таблице", nil)
+		apiErr.Body["table"] = tableName
+		apiErr.Body["unknown"] = unknown
+		return apiErr
+	}
+	return nil
+}
+
+// createImportTable создает таблицу для ImportTable по заголовкам и типам, определенным по
+// данным, и сохраняет ее метаданные. Возвращает схему для ответа и признак таблицы без ключа
+func createImportTable(tx *gorm.DB, tableName string, headers []string, records [][]string) ([]gin.H, bool, error) {
+	types := inferColumnTypes(headers, records)
+	requirePK := initializers.GetConfig().RequirePrimaryKey
+
+	var columns, metaColumns []string
+	var schema []gin.H
+	hasID := false
+	for i, h := range headers {
+		definition := fmt.Sprintf("%s %s", quoteIdentifier(h), types[i])
+		metaColumn := fmt.Sprintf("%s:%s", h, types[i])
+		if h == "id" {
+			hasID = true
+			if requirePK {
+				definition += " PRIMARY KEY"
+				metaColumn += ":pk"
+			}
+		}
+		columns = append(columns, definition)
+		metaColumns = append(metaColumns, metaColumn)
+		schema = append(schema, gin.H{"name": h, "type": types[i]})
+	}
+	if !hasID {
+		columns = append([]string{"id SERIAL PRIMARY KEY"}, columns...)
+		metaColumns = append([]string{"id:SERIAL"}, metaColumns...)
+		schema = append([]gin.H{{"name": "id", "type": "SERIAL"}}, schema...)
+	}
+
+	sql := fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", quoteIdentifier(tableName), strings.Join(columns, ",\n  "))
+	if err := tx.Exec(sql).Error; err != nil {
+		return nil, false, newAPIError(http.StatusInternalServerError, "Ошибка выполнения SQL", err)
+	}
+
+	columnsJSON, err := json.Marshal(metaColumns)
+	if err != nil {
+		return nil, false, newAPIError(http.StatusInternalServerError, "Ошибка сериализации колонок", err)
+	}
+	meta := model.TableMeta{
+		Name:    tableName,
+		Columns: string(columnsJSON),
+	}
+	if err := tx.Create(&meta).Error; err != nil {
+		return nil, false, newAPIError(http.StatusInternalServerError, "Ошибка сохранения метаданных", err)
+	}
+	return schema, hasID && !requirePK, nil
+}
+
+// restoreTableFromZip пересоздает таблицу из CSV в архиве. Типы колонок берутся
+// из метаданных, а если их нет — определяются по значениям в CSV
+func restoreTableFromZip(tx *gorm.DB, zipFile *zip.File, tableName string, declared map[string]string, dialect csvDialect) error {
+	headers, records, err := readZipCSV(zipFile, dialect)
 	if err != nil {
 		return err
 	}
 
+	// Определяем типы колонок
+	types := make([]string, len(headers))
+	for i, h := range headers {
+		types[i] = declared[h]
+	}
+	for _, t := range types {
+		if t == "" {
+			types = inferColumnTypes(headers, records)
+			break
+		}
+	}
+
 	// Удаляем старую таблицу если есть
-	if err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)).Error; err != nil {
+	if err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", quoteIdentifier(tableName))).Error; err != nil {
 		return err
 	}
 
 	// Создаем новую таблицу
 	columns := make([]string, len(headers))
+	emptyIsNull := make([]bool, len(headers))
 	for i, h := range headers {
-		columns[i] = fmt.Sprintf("%s TEXT", h) // При восстановлении все колонки TEXT
+		columns[i] = fmt.Sprintf("%s %s", quoteIdentifier(h), types[i])
+		emptyIsNull[i] = types[i] != "TEXT"
 	}
 
-	createSQL := fmt.Sprintf("CREATE TABLE %s (%s)", tableName, strings.Join(columns, ", "))
+	createSQL := fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(tableName), strings.Join(columns, ", "))
 	if err := tx.Exec(createSQL).Error; err != nil {
 		return err
 	}
 
-	// Вставляем данные
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
+	return insertCSVRecords(tx, tableName, headers, records, emptyIsNull)
+}
+
+// restoreTableDataFromZip добавляет строки CSV в существующую таблицу, не меняя ее структуру.
+// Все колонки CSV должны существовать в таблице
+func restoreTableDataFromZip(tx *gorm.DB, zipFile *zip.File, tableName string, dialect csvDialect) error {
+	headers, records, err := readZipCSV(zipFile, dialect)
+	if err != nil {
+		return err
+	}
+
+	columnTypes, err := getColumnTypes(tx, tableName)
+	if err != nil {
+		return err
+	}
+	if len(columnTypes) == 0 {
+		return fmt.Errorf("таблица %s не существует", tableName)
+	}
+
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		types[col.ColumnName] = col.DataType
+	}
+
+	emptyIsNull := make([]bool, len(headers))
+	for i, h := range headers {
+		dataType, ok := types[h]
+		if !ok {
+			return fmt.Errorf("колонка '%s' отсутствует в таблице %s", h, tableName)
 		}
+		emptyIsNull[i] = !isTextType(dataType)
+	}
+
+	return insertCSVRecords(tx, tableName, headers, records, emptyIsNull)
+}
+
+// readZipCSV читает CSV из архива: заголовки и строки. Файл должен быть в UTF-8
+func readZipCSV(zipFile *zip.File, dialect csvDialect) ([]string, [][]string, error) {
+	rc, err := zipFile.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, nil, fmt.Errorf("файл %s не в кодировке UTF-8", zipFile.Name)
+	}
+
+	reader := dialect.newReader(data)
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	return headers, records, nil
+}
 
-		values := make([]string, len(record))
+// insertCSVRecords вставляет строки CSV в таблицу. "NULL" всегда вставляется как NULL,
+// пустая строка — как NULL в колонках, отмеченных в emptyIsNull (нетекстовых)
+func insertCSVRecords(tx *gorm.DB, tableName string, headers []string, records [][]string, emptyIsNull []bool) error {
+	quoted := make([]string, len(headers))
+	placeholders := make([]string, len(headers))
+	for i, h := range headers {
+		quoted[i] = quoteIdentifier(h)
+		placeholders[i] = "?"
+	}
+
+	insertSQL := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdentifier(tableName),
+		strings.Join(quoted, ", "),
+		strings.Join(placeholders, ", "))
+
+	for _, record := range records {
+		values := make([]interface{}, len(record))
 		for i, v := range record {
-			// Экранируем специальные символы
-			v = strings.ReplaceAll(v, "'", "''")
-			values[i] = fmt.Sprintf("'%s'", v)
+			if v == "NULL" || (v == "" && i < len(emptyIsNull) && emptyIsNull[i]) {
+				values[i] = nil
+			} else {
+				values[i] = v
+			}
 		}
 
-		insertSQL := fmt.Sprintf("INSERT INTO %s VALUES (%s)", tableName, strings.Join(values, ", "))
-		if err := tx.Exec(insertSQL).Error; err != nil {
+		if err := tx.Exec(insertSQL, values...).Error; err != nil {
 			return err
 		}
 	}
@@ -1207,6 +3118,20 @@ func restoreTableFromZip(tx *gorm.DB, zipFile *zip.File, tableName string) error
 	return nil
 }
 
+// declaredColumnTypes разбирает колонки из метаданных ("name:type") в карту имя -> тип
+func declaredColumnTypes(meta model.TableMeta) map[string]string {
+	var specs []string
+	json.Unmarshal([]byte(meta.Columns), &specs)
+
+	types := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		if col, err := parseColumnSpec(spec); err == nil {
+			types[col.Name] = col.Type
+		}
+	}
+	return types
+}
+
 func getPrimaryKeyColumn(db *gorm.DB, tableName string) (string, error) {
 	var pkColumn string
 	query := `
@@ -1216,9 +3141,36 @@ func getPrimaryKeyColumn(db *gorm.DB, tableName string) (string, error) {
         WHERE i.indrelid = $1::regclass
         AND i.indisprimary;
     `
-	row := db.Raw(query, tableName).Row()
+	row := db.Raw(query, quoteIdentifier(tableName)).Row()
 	if err := row.Scan(&pkColumn); err != nil {
-		return "", fmt.Errorf("не удалось определить первичный ключ: %v", err)
+		return "", primaryKeyLookupError(err)
 	}
 	return pkColumn, nil
 }
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+// validateRowID проверяет, что идентификатор строки соответствует типу первичного ключа
+// (число для целочисленных ключей, UUID для uuid)
+func validateRowID(db *gorm.DB, tableName, pkColumn, rowID string) error {
+	var dataType string
+	if err := db.Raw(`
+		SELECT data_type
+		FROM information_schema.columns
+		WHERE table_name = ? AND column_name = ?
+	`, tableName, pkColumn).Scan(&dataType).Error; err != nil {
+		return err
+	}
+
+	switch {
+	case dataType == "uuid":
+		if !uuidPattern.MatchString(rowID) {
+			return fmt.Errorf("идентификатор '%s' не является UUID", rowID)
+		}
+	case isIntegerType(dataType):
+		if _, err := strconv.ParseInt(rowID, 10, 64); err != nil {
+			return fmt.Errorf("идентификатор '%s' не является числом", rowID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDropColumnRejectsInvalidIdentifiers проверяет, что имена из пути не попадают в SQL
+// без проверки: ответ 400 приходит до обращения к БД
+func TestDropColumnRejectsInvalidIdentifiers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/api/tables/:name/columns/:column", DropColumn)
+
+	paths := []string{
+		"/api/tables/users/columns/name;DROP%20TABLE%20users",
+		"/api/tables/users%3BDROP%20TABLE%20x/columns/name",
+		"/api/tables/users/columns/na-me",
+	}
+	for _, path := range paths {
+		status, resp := doJSON(t, r, http.MethodDelete, path, nil)
+		if status != http.StatusBadRequest {
+			t.Errorf("DELETE %s: %d %v, want 400", path, status, resp)
+		}
+	}
+}
+
+// TestAddColumnRejectsInvalidType проверяет, что тип колонки проверяется по каталогу
+// до обращения к БД: он подставляется в ALTER TABLE без экранирования
+func TestAddColumnRejectsInvalidType(t *testing.T) {
+	setTestEnv(t, nil)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/tables/:name/columns", AddColumn)
+
+	for _, colType := range []string{"TEXT; DROP TABLE users", "money", "VARCHAR(10)"} {
+		status, resp := doJSON(t, r, http.MethodPost, "/api/tables/users/columns", gin.H{"name": "note", "type": colType})
+		if status != http.StatusBadRequest || resp["allowed"] == nil {
+			t.Errorf("тип %q: %d %v, want 400 с allowed", colType, status, resp)
+		}
+	}
+}
+
+func TestDropColumnMissingColumn(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_drop_column")
+	if err := db.Exec(`CREATE TABLE test_drop_column (id SERIAL PRIMARY KEY, "order" TEXT)`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.DELETE("/api/tables/:name/columns/:column", DropColumn)
+	})
+
+	if status, resp := doJSON(t, r, http.MethodDelete, "/api/tables/test_drop_column/columns/missing", nil); status != http.StatusNotFound {
+		t.Fatalf("отсутствующая колонка: %d %v, want 404", status, resp)
+	}
+	// Зарезервированное слово удаляется благодаря кавычкам
+	if status, resp := doJSON(t, r, http.MethodDelete, "/api/tables/test_drop_column/columns/order", nil); status != http.StatusOK {
+		t.Fatalf("колонка order: %d %v, want 200", status, resp)
+	}
+	if status, resp := doJSON(t, r, http.MethodDelete, "/api/tables/test_missing_table/columns/id", nil); status != http.StatusNotFound {
+		t.Fatalf("отсутствующая таблица: %d %v, want 404", status, resp)
+	}
+}
+
+func TestUpdateColumnOptions(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_column_options")
+	if err := db.Exec(`CREATE TABLE test_column_options (id SERIAL PRIMARY KEY, note TEXT)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`INSERT INTO test_column_options (note) VALUES (NULL)`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.PATCH("/api/tables/:name/columns/:column", UpdateColumnOptions)
+	})
+
+	tests := []struct {
+		path   string
+		body   map[string]interface{}
+		status int
+	}{
+		{"/api/tables/test_column_options/columns/missing", map[string]interface{}{"nullable": true}, http.StatusNotFound},
+		{"/api/tables/test_column_options/columns/note", map[string]interface{}{"nullable": false}, http.StatusConflict},
+		{"/api/tables/test_column_options/columns/note", map[string]interface{}{"default": "x"}, http.StatusOK},
+		{"/api/tables/test_column_options/columns/note", map[string]interface{}{"default": nil, "nullable": true}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if status, resp := doJSON(t, r, http.MethodPatch, tt.path, tt.body); status != tt.status {
+			t.Errorf("PATCH %s %v: %d %v, want %d", tt.path, tt.body, status, resp, tt.status)
+		}
+	}
+}
+
+// TestAlterColumnDebugSQL проверяет, что SQL в ответе AlterColumn есть только с ?debugSql=true
+// при DEBUG_SQL_RESPONSES=true, а для отсутствующей таблицы приходит 404
+func TestAlterColumnDebugSQL(t *testing.T) {
+	db := testDB(t)
+	setTestEnv(t, map[string]string{"DEBUG_SQL_RESPONSES": "true"})
+	dropTestTable(t, db, "test_alter_column")
+	if err := db.Exec(`CREATE TABLE test_alter_column (id SERIAL PRIMARY KEY, a TEXT)`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.PUT("/api/tables/:name/columns/:column", AlterColumn)
+	})
+
+	status, resp := doJSON(t, r, http.MethodPut, "/api/tables/test_alter_column/columns/a", map[string]interface{}{"action": "rename", "newName": "b"})
+	if status != http.StatusOK {
+		t.Fatalf("rename: %d %v", status, resp)
+	}
+	if _, ok := resp["sql"]; ok {
+		t.Fatalf("sql без debugSql: %v", resp)
+	}
+
+	status, resp = doJSON(t, r, http.MethodPut, "/api/tables/test_alter_column/columns/b?debugSql=true", map[string]interface{}{"action": "rename", "newName": "c"})
+	if status != http.StatusOK || resp["sql"] == nil {
+		t.Fatalf("rename с debugSql: %d %v, want sql в ответе", status, resp)
+	}
+
+	status, resp = doJSON(t, r, http.MethodPut, "/api/tables/test_missing_table/columns/a", map[string]interface{}{"action": "rename", "newName": "b"})
+	if status != http.StatusNotFound || resp["table"] == nil {
+		t.Fatalf("отсутствующая таблица: %d %v, want 404 с table", status, resp)
+	}
+}
+
+// TestCreateTableLowercaseByDefault проверяет, что при IDENTIFIER_CASE по умолчанию имя
+// в смешанном регистре сохраняется в нижнем, а повтор в другом регистре получает 409
+func TestCreateTableLowercaseByDefault(t *testing.T) {
+	db := testDB(t)
+	setTestEnv(t, map[string]string{"IDENTIFIER_CASE": ""})
+	dropTestTable(t, db, "test_mytable")
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.POST("/api/tables", CreateTable)
+	})
+
+	status, resp := doJSON(t, r, http.MethodPost, "/api/tables", gin.H{"name": "Test_MyTable", "columns": []string{"Order:TEXT"}})
+	if status != http.StatusCreated || resp["table"] != "test_mytable" {
+		t.Fatalf("CreateTable: %d %v, want 201 с table test_mytable", status, resp)
+	}
+	if exists, err := columnExists(db, "test_mytable", "order"); err != nil || !exists {
+		t.Fatalf("колонка order не создана (err=%v)", err)
+	}
+
+	if status, resp := doJSON(t, r, http.MethodPost, "/api/tables", gin.H{"name": "TEST_MYTABLE", "columns": []string{"title:TEXT"}}); status != http.StatusConflict {
+		t.Fatalf("повтор в другом регистре: %d %v, want 409", status, resp)
+	}
+}
@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+// Readyz сообщает, готов ли сервис принимать запросы: 200, если последняя проверка
+// подключения к БД прошла успешно, иначе 503. БД при этом не опрашивается
+func Readyz(c *gin.Context) {
+	health := initializers.GetDBHealth()
+	if !health.Healthy {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "db": health})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "db": health})
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"server/initializers"
+)
+
+// pgMaxIdentifierLength — длина в байтах, до которой Postgres молча обрезает идентификаторы
+const pgMaxIdentifierLength = 63
+
+// reservedWords — зарезервированные ключевые слова PostgreSQL,
+// которые нельзя использовать как идентификаторы без кавычек
+var reservedWords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true,
+	"array": true, "as": true, "asc": true, "asymmetric": true, "authorization": true,
+	"binary": true, "both": true, "case": true, "cast": true, "check": true,
+	"collate": true, "collation": true, "column": true, "concurrently": true,
+	"constraint": true, "create": true, "cross": true, "current_catalog": true,
+	"current_date": true, "current_role": true, "current_schema": true,
+	"current_time": true, "current_timestamp": true, "current_user": true,
+	"default": true, "deferrable": true, "desc": true, "distinct": true, "do": true,
+	"else": true, "end": true, "except": true, "false": true, "fetch": true,
+	"for": true, "foreign": true, "freeze": true, "from": true, "full": true,
+	"grant": true, "group": true, "having": true, "ilike": true, "in": true,
+	"initially": true, "inner": true, "intersect": true, "into": true, "is": true,
+	"isnull": true, "join": true, "lateral": true, "leading": true, "left": true,
+	"like": true, "limit": true, "localtime": true, "localtimestamp": true,
+	"natural": true, "not": true, "notnull": true, "null": true, "offset": true,
+	"on": true, "only": true, "or": true, "order": true, "outer": true,
+	"overlaps": true, "placing": true, "primary": true, "references": true,
+	"returning": true, "right": true, "select": true, "session_user": true,
+	"similar": true, "some": true, "symmetric": true, "table": true,
+	"tablesample": true, "then": true, "to": true, "trailing": true, "true": true,
+	"union": true, "unique": true, "user": true, "using": true, "variadic": true,
+	"verbose": true, "when": true, "where": true, "window": true, "with": true,
+}
+
+// isReservedWord проверяет, является ли имя зарезервированным словом SQL
+func isReservedWord(s string) bool {
+	return reservedWords[strings.ToLower(s)]
+}
+
+// checkIdentifierLength отклоняет имена длиннее IDENTIFIER_MAX_LENGTH (по умолчанию 63 байта),
+// чтобы Postgres не обрезал их без предупреждения
+func checkIdentifierLength(name string) error {
+	maxLength := initializers.GetConfig().IdentifierMaxLength
+	if len(name) > maxLength {
+		return fmt.Errorf("имя '%s' длиннее %d байт (%d)", name, maxLength, len(name))
+	}
+	return nil
+}
+
+// validateTableName проверяет имя таблицы из запроса: допустимые символы и длину
+func validateTableName(name string) *apiError {
+	if !isValidIdentifier(name) {
+		apiErr := newAPIError(http.StatusBadRequest, "Некорректное имя таблицы", nil)
+		apiErr.Body["requirements"] = "Должно начинаться с буквы и содержать только a-z, 0-9, _"
+		apiErr.Body["received"] = name
+		return apiErr
+	}
+	if err := checkIdentifierLength(name); err != nil {
+		return newAPIError(http.StatusBadRequest, "Слишком длинное имя таблицы", err)
+	}
+	return nil
+}
+
+// validateColumnName проверяет имя колонки из запроса; position — ее номер (с 1) в запросе
+func validateColumnName(name string, position int) *apiError {
+	if !isValidIdentifier(name) {
+		apiErr := newAPIError(http.StatusBadRequest, "Некорректное имя колонки", nil)
+		apiErr.Body["position"] = position
+		apiErr.Body["name"] = name
+		return apiErr
+	}
+	if err := checkIdentifierLength(name); err != nil {
+		apiErr := newAPIError(http.StatusBadRequest, "Слишком длинное имя колонки", err)
+		apiErr.Body["position"] = position
+		return apiErr
+	}
+	return nil
+}
+
+// truncatedIdentifier возвращает имя в том виде, в котором его сохранит Postgres:
+// не длиннее 63 байт, обрезанное по границе символа, а не посреди многобайтного символа.
+// Нужен для имен, которые строит сервер (ограничения, временные имена колонок);
+// имена от клиента отклоняются checkIdentifierLength до обрезки
+func truncatedIdentifier(name string) string {
+	if len(name) <= pgMaxIdentifierLength {
+		return name
+	}
+	cut := pgMaxIdentifierLength
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	return name[:cut]
+}
+
+// quoteIdentifier экранирует идентификатор двойными кавычками,
+// чтобы зарезервированные слова и спецсимволы не ломали SQL
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// quoteLiteral экранирует строку как SQL-литерал в одинарных кавычках
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// maxExportFilenameLength — предельная длина имени выгружаемого файла в символах
+const maxExportFilenameLength = 200
+
+// exportFilename очищает имя файла от клиента: отбрасывает путь и управляющие символы
+// и добавляет расширение .csv, если его нет. Для пустого имени возвращает fallback
+func exportFilename(name, fallback string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	if name == "" {
+		return fallback
+	}
+	if !strings.EqualFold(path.Ext(name), ".csv") {
+		name += ".csv"
+	}
+	if runes := []rune(name); len(runes) > maxExportFilenameLength {
+		name = string(runes[:maxExportFilenameLength-len(".csv")]) + ".csv"
+	}
+	return name
+}
+
+// attachmentDisposition формирует Content-Disposition для скачивания файла,
+// экранируя имя так, чтобы кавычки и не-ASCII символы не ломали заголовок
+func attachmentDisposition(filename string) string {
+	ascii := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, filename)
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, ascii, url.PathEscape(filename))
+}
+
+// allowlistCondition строит SQL-условие для TABLE_ALLOWLIST по колонке с именем таблицы.
+// Если список не задан, возвращает пустое условие
+func allowlistCondition(column string) (string, []interface{}) {
+	names, prefixes, enabled := initializers.TableAllowlist()
+	if !enabled {
+		return "", nil
+	}
+
+	conditions := []string{"FALSE"}
+	var args []interface{}
+	if len(names) > 0 {
+		conditions = append(conditions, column+" IN ?")
+		args = append(args, names)
+	}
+	for _, prefix := range prefixes {
+		conditions = append(conditions, column+" LIKE ?")
+		args = append(args, escapeLike(prefix)+"%")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// forbiddenTables возвращает таблицы из списка, не разрешенные TABLE_ALLOWLIST
+func forbiddenTables(tables []string) []string {
+	var forbidden []string
+	for _, table := range tables {
+		if !initializers.TableAllowed(table) {
+			forbidden = append(forbidden, table)
+		}
+	}
+	return forbidden
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+// TestIdentifierCaseRoundTrip создает таблицу со смешанным регистром имени и проверяет,
+// что при обеих политиках IDENTIFIER_CASE ее находят поиск, чтение строки по ключу и удаление
+func TestIdentifierCaseRoundTrip(t *testing.T) {
+	db := testDB(t)
+
+	tests := []struct {
+		policy string
+		stored string
+	}{
+		{initializers.IdentifierCaseLower, "test_roundtrip_lower"},
+		{initializers.IdentifierCasePreserve, "Test_RoundTrip_Preserve"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			setTestEnv(t, map[string]string{"IDENTIFIER_CASE": tt.policy})
+			dropTestTable(t, db, tt.stored)
+
+			r := newTestRouter(func(r *gin.Engine) {
+				r.POST("/api/tables", CreateTable)
+				r.POST("/api/tables/:name/rows", AddRow)
+				r.GET("/api/tables/:name/data", GetTableData)
+				r.GET("/api/tables/:name/rows/:id", GetRow)
+				r.DELETE("/api/tables/:name", DropTable)
+			})
+
+			// Клиент всегда передает имя в смешанном регистре
+			name := "Test_RoundTrip_" + strings.ToUpper(tt.policy[:1]) + tt.policy[1:]
+
+			status, resp := doJSON(t, r, http.MethodPost, "/api/tables", gin.H{"name": name, "columns": []string{"Title:TEXT"}})
+			if status != http.StatusCreated {
+				t.Fatalf("CreateTable: %d %v", status, resp)
+			}
+			exists, err := tableExists(db, tt.stored)
+			if err != nil || !exists {
+				t.Fatalf("таблица %q не создана (err=%v)", tt.stored, err)
+			}
+
+			title := "Title"
+			if tt.policy == initializers.IdentifierCaseLower {
+				title = "title"
+			}
+			base := "/api/tables/" + name
+			if status, resp := doJSON(t, r, http.MethodPost, base+"/rows", gin.H{title: "first"}); status != http.StatusOK {
+				t.Fatalf("AddRow: %d %v", status, resp)
+			}
+
+			status, resp = doJSON(t, r, http.MethodGet, base+"/data", nil)
+			if status != http.StatusOK {
+				t.Fatalf("GetTableData: %d %v", status, resp)
+			}
+			rows, _ := resp["rows"].([]interface{})
+			if len(rows) != 1 {
+				t.Fatalf("GetTableData: ожидалась одна строка, получено %v", resp["rows"])
+			}
+			id := rows[0].(map[string]interface{})["id"]
+
+			// GetRow ищет первичный ключ через ::regclass — имя должно передаваться в кавычках
+			status, resp = doJSON(t, r, http.MethodGet, fmt.Sprintf("%s/rows/%v", base, id), nil)
+			if status != http.StatusOK {
+				t.Fatalf("GetRow: %d %v", status, resp)
+			}
+			if row, _ := resp["data"].(map[string]interface{}); row == nil || row[title] != "first" {
+				t.Fatalf("GetRow: неожиданный ответ %v", resp)
+			}
+
+			if status, resp := doJSON(t, r, http.MethodDelete, base, nil); status != http.StatusOK {
+				t.Fatalf("DropTable: %d %v", status, resp)
+			}
+		})
+	}
+}
+
+func TestTruncatedIdentifier(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	cyrillic := strings.Repeat("я", 35) // 70 байт: обрезка на 63-м байте пришлась бы на середину символа
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"short", "orders", "orders"},
+		{"exactly 63 bytes", strings.Repeat("b", 63), strings.Repeat("b", 63)},
+		{"70 ascii", long, long[:63]},
+		{"70 bytes cyrillic", cyrillic, strings.Repeat("я", 31)},
+		{"mixed", "t_" + cyrillic, "t_" + strings.Repeat("я", 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncatedIdentifier(tt.in)
+			if got != tt.want {
+				t.Fatalf("truncatedIdentifier() = %q (%d байт), want %q", got, len(got), tt.want)
+			}
+			if !utf8.ValidString(got) || len(got) > pgMaxIdentifierLength {
+				t.Fatalf("truncatedIdentifier() = %q: некорректный UTF-8 или длиннее 63 байт", got)
+			}
+		})
+	}
+}
+
+func TestCheckIdentifierLength(t *testing.T) {
+	setTestEnv(t, map[string]string{"IDENTIFIER_MAX_LENGTH": ""})
+
+	if err := checkIdentifierLength(strings.Repeat("a", 63)); err != nil {
+		t.Fatalf("63 байта: %v", err)
+	}
+	if err := checkIdentifierLength(strings.Repeat("a", 70)); err == nil {
+		t.Fatal("имя из 70 символов принято")
+	}
+
+	setTestEnv(t, map[string]string{"IDENTIFIER_MAX_LENGTH": "10"})
+	if err := checkIdentifierLength("abcdefghijk"); err == nil {
+		t.Fatal("IDENTIFIER_MAX_LENGTH=10: имя из 11 символов принято")
+	}
+}
+
+// TestLongIdentifiersRejected проверяет, что имя из 70 символов отклоняется с 400
+// до обращения к БД, а не обрезается Postgres молча
+func TestLongIdentifiersRejected(t *testing.T) {
+	setTestEnv(t, nil)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/tables", CreateTable)
+	r.POST("/api/tables/:name/columns", AddColumn)
+	r.POST("/api/tables/:name/columns/rename-batch", RenameColumnsBatch)
+
+	long := "c_" + strings.Repeat("x", 68)
+	requests := []struct {
+		path string
+		body interface{}
+	}{
+		{"/api/tables", gin.H{"name": long, "columns": []string{"title:TEXT"}}},
+		{"/api/tables/orders/columns", gin.H{"name": long, "type": "TEXT"}},
+		{"/api/tables/orders/columns/rename-batch", []gin.H{{"from": "title", "to": long}}},
+	}
+	for _, req := range requests {
+		status, resp := doJSON(t, r, http.MethodPost, req.path, req.body)
+		if status != http.StatusBadRequest {
+			t.Errorf("POST %s: %d %v, want 400", req.path, status, resp)
+		}
+	}
+}
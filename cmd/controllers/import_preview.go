@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Пределы предпросмотра CSV
+const (
+	defaultPreviewRows = 10
+	maxPreviewRows     = 100
+	maxPreviewIssues   = 100
+)
+
+// previewColumn — колонка CSV и то, куда она попадет при восстановлении
+type previewColumn struct {
+	Name         string `json:"name"`
+	InferredType string `json:"inferredType"`
+	Target       string `json:"target,omitempty"` // колонка таблицы, пусто — колонка будет пропущена
+	TargetType   string `json:"targetType,omitempty"`
+}
+
+// previewIssue — значение или строка CSV, на которых восстановление завершится ошибкой
+type previewIssue struct {
+	Line   int    `json:"line"`
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Error  string `json:"error"`
+}
+
+// PreviewImport разбирает загруженный CSV так же, как RestoreTable (?header=, ?columns=, ?columnMap=,
+// ?partial=, ?delimiter=, ?quote=, ?lazyQuotes=), и ничего не записывает. Возвращает заголовки,
+// первые ?rows= строк, определенные по данным типы и расхождения со схемой таблицы
+func PreviewImport(c *gin.Context) {
+	tableName := c.Param("name")
+
+	sampleSize := defaultPreviewRows
+	if raw := c.Query("rows"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxPreviewRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("некорректный rows '%s', допустимо от 1 до %d", raw, maxPreviewRows)})
+			return
+		}
+		sampleSize = n
+	}
+
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	exists := len(columnTypes) > 0
+	tableColumns := make([]string, 0, len(columnTypes))
+	types := make(map[string]string, len(columnTypes))
+	known := make(map[string]bool, len(columnTypes))
+	for _, col := range columnTypes {
+		tableColumns = append(tableColumns, col.ColumnName)
+		types[col.ColumnName] = col.DataType
+		known[col.ColumnName] = true
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл не загружен"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка открытия файла"})
+		return
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения файла"})
+		return
+	}
+	if err := checkCSVData(data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный файл", "details": err.Error()})
+		return
+	}
+
+	dialect, err := parseCSVDialect(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reader := dialect.newReader(data)
+	reader.FieldsPerRecord = -1
+	hasHeader := c.Query("header") != "false"
+
+	var headers []string
+	positional := false
+	if raw := c.Query("columns"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			headers = append(headers, strings.TrimSpace(name))
+		}
+	} else if !hasHeader {
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Без заголовка CSV и ?columns= нужна существующая таблица"})
+			return
+		}
+		headers = tableColumns
+		positional = true
+	}
+	if hasHeader {
+		first, err := reader.Read()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения CSV"})
+			return
+		}
+		if headers == nil {
+			headers = first
+		}
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения строки CSV", "details": err.Error()})
+		return
+	}
+
+	columnMap, err := parseColumnMap(c.Query("columnMap"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	partial := c.Query("partial") == "true" || columnMap != nil
+
+	inferred := inferColumnTypes(headers, records)
+	columns := make([]previewColumn, len(headers))
+	for i, h := range headers {
+		columns[i] = previewColumn{Name: h, InferredType: inferred[i]}
+	}
+
+	sample := records
+	if len(sample) > sampleSize {
+		sample = sample[:sampleSize]
+	}
+
+	response := gin.H{
+		"table":       tableName,
+		"tableExists": exists,
+		"headers":     headers,
+		"columns":     columns,
+		"sample":      sample,
+		"rows":        len(records),
+	}
+	if !exists {
+		// Таблицу создаст ImportTable с ?createIfMissing=true по определенным типам
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Сопоставление всегда строим как при partial, чтобы показать все лишние колонки
+	mapping, err := mapRestoreColumns(headers, columnMap, known, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "columns": tableColumns})
+		return
+	}
+	for i, idx := range mapping.indices {
+		columns[idx].Target = mapping.Targets[i]
+		columns[idx].TargetType = types[mapping.Targets[i]]
+	}
+
+	issues := []previewIssue{}
+	issueCount := 0
+	addIssue := func(issue previewIssue) {
+		issueCount++
+		if len(issues) < maxPreviewIssues {
+			issues = append(issues, issue)
+		}
+	}
+	if !partial {
+		for _, name := range mapping.Ignored {
+			addIssue(previewIssue{Column: name, Error: "колонка не найдена в таблице, используйте ?partial=true или ?columnMap="})
+		}
+	}
+
+	for line, record := range records {
+		if len(record) != len(headers) && !(positional && len(record) < len(headers)) {
+			addIssue(previewIssue{
+				Line:  line + 1,
+				Error: fmt.Sprintf("количество значений (%d) не совпадает с количеством колонок (%d)", len(record), len(headers)),
+			})
+			continue
+		}
+		for i, idx := range mapping.indices {
+			if idx >= len(record) || record[idx] == "NULL" {
+				continue
+			}
+			target := mapping.Targets[i]
+			if _, err := coerceInput(record[idx], types[target]); err != nil {
+				addIssue(previewIssue{Line: line + 1, Column: target, Value: record[idx], Error: err.Error()})
+			}
+		}
+	}
+
+	response["ignored"] = mapping.Ignored
+	response["defaulted"] = mapping.defaulted(tableColumns)
+	response["issueCount"] = issueCount
+	response["issues"] = issues
+	c.JSON(http.StatusOK, response)
+}
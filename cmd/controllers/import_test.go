@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateColumnName(t *testing.T) {
+	setTestEnv(t, nil)
+
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"price", 0},
+		{"order", 0},
+		{"_total", 0},
+		{"na-me", http.StatusBadRequest},
+		{"1col", http.StatusBadRequest},
+		{"a b", http.StatusBadRequest},
+		{strings.Repeat("a", 63), 0},
+		{strings.Repeat("a", 70), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		apiErr := validateColumnName(tt.name, 1)
+		switch {
+		case tt.status == 0 && apiErr != nil:
+			t.Errorf("%q: %v, want ok", tt.name, apiErr)
+		case tt.status != 0 && (apiErr == nil || apiErr.Status != tt.status):
+			t.Errorf("%q: %v, want %d", tt.name, apiErr, tt.status)
+		}
+		if apiErr == nil {
+			if err := validateTableName(tt.name); err != nil {
+				t.Errorf("имя таблицы %q: %v, want ok", tt.name, err)
+			}
+		}
+	}
+}
+
+// TestImportTableRejectsBadHeaders проверяет, что заголовки CSV проверяются до обращения к БД
+func TestImportTableRejectsBadHeaders(t *testing.T) {
+	setTestEnv(t, nil)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/tables/:name/import", ImportTable)
+
+	files := map[string]string{
+		"некорректное имя":  "id,na-me\n1,x\n",
+		"длинное имя":       "id," + strings.Repeat("a", 70) + "\n1,x\n",
+		"дублирующееся имя": "id,name,name\n1,x,y\n",
+	}
+	for title, data := range files {
+		if w := restoreRequest(t, r, "/api/tables/test_import/import", data); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d %s, want 400", title, w.Code, w.Body.String())
+		}
+	}
+	long := "/api/tables/" + strings.Repeat("t", 70) + "/import"
+	if w := restoreRequest(t, r, long, "id\n1\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("длинное имя таблицы: %d %s, want 400", w.Code, w.Body.String())
+	}
+}
+
+func TestImportTable(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_import")
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.POST("/api/tables/:name/import", ImportTable)
+	})
+
+	if w := restoreRequest(t, r, "/api/tables/test_import/import", "id,name\n1,a\n"); w.Code != http.StatusNotFound {
+		t.Fatalf("без createIfMissing: %d %s, want 404", w.Code, w.Body.String())
+	}
+
+	// Колонка id из CSV становится первичным ключом
+	w := restoreRequest(t, r, "/api/tables/test_import/import?createIfMissing=true", "id,name\n1,a\n2,b\n")
+	if w.Code != http.StatusOK {
+		t.Fatalf("создание: %d %s", w.Code, w.Body.String())
+	}
+	pk, err := getPrimaryKeyColumn(db, "test_import")
+	if err != nil || pk != "id" {
+		t.Fatalf("первичный ключ: %q %v, want id", pk, err)
+	}
+
+	// Заголовки сверяются с колонками существующей таблицы
+	if w := restoreRequest(t, r, "/api/tables/test_import/import", "id,missing\n3,c\n"); w.Code != http.StatusBadRequest {
+		t.Fatalf("неизвестная колонка: %d %s, want 400", w.Code, w.Body.String())
+	}
+	if w := restoreRequest(t, r, "/api/tables/test_import/import", "name,id\nc,3\n"); w.Code != http.StatusOK {
+		t.Fatalf("импорт в существующую таблицу: %d %s", w.Code, w.Body.String())
+	}
+
+	var count int64
+	db.Table("test_import").Count(&count)
+	if count != 3 {
+		t.Fatalf("строк в таблице: %d, want 3", count)
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// inferSampleSize — сколько строк CSV просматриваем для определения типов
+const inferSampleSize = 100
+
+// timestampLayouts — форматы дат, которые распознаются при определении типа
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func isIntegerValue(v string) bool {
+	_, err := strconv.ParseInt(v, 10, 64)
+	return err == nil
+}
+
+func isFloatValue(v string) bool {
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+func isBooleanValue(v string) bool {
+	switch strings.ToLower(v) {
+	case "true", "false":
+		return true
+	}
+	return false
+}
+
+func isTimestampValue(v string) bool {
+	for _, layout := range timestampLayouts {
+		if _, err := time.Parse(layout, v); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// inferColumnType подбирает тип колонки по выборке значений.
+// Пустые значения и NULL пропускаются; если значений нет — TEXT
+func inferColumnType(values []string) string {
+	checks := []struct {
+		sqlType string
+		match   func(string) bool
+	}{
+		{"INTEGER", isIntegerValue},
+		{"FLOAT", isFloatValue},
+		{"BOOLEAN", isBooleanValue},
+		{"TIMESTAMP", isTimestampValue},
+	}
+
+	var sample []string
+	for _, v := range values {
+		if v == "" || v == "NULL" {
+			continue
+		}
+		sample = append(sample, v)
+	}
+	if len(sample) == 0 {
+		return "TEXT"
+	}
+
+	for _, check := range checks {
+		matched := true
+		for _, v := range sample {
+			if !check.match(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return check.sqlType
+		}
+	}
+	return "TEXT"
+}
+
+// inferColumnTypes определяет типы для каждой колонки по первым строкам CSV
+func inferColumnTypes(headers []string, records [][]string) []string {
+	limit := len(records)
+	if limit > inferSampleSize {
+		limit = inferSampleSize
+	}
+
+	types := make([]string, len(headers))
+	for i := range headers {
+		values := make([]string, 0, limit)
+		for _, record := range records[:limit] {
+			if i < len(record) {
+				values = append(values, record[i])
+			}
+		}
+		types[i] = inferColumnType(values)
+	}
+	return types
+}
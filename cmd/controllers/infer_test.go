@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInferColumnType(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"integers", []string{"1", "-2", "300"}, "INTEGER"},
+		{"integers and floats", []string{"1", "2.5"}, "FLOAT"},
+		{"floats", []string{"0.1", "1e3"}, "FLOAT"},
+		{"booleans", []string{"true", "FALSE", "True"}, "BOOLEAN"},
+		{"dates", []string{"2024-01-02", "2024-01-02 15:04:05", "2024-01-02T15:04:05Z"}, "TIMESTAMP"},
+		{"text", []string{"abc", "1"}, "TEXT"},
+		{"empty and NULL skipped", []string{"", "NULL", "7"}, "INTEGER"},
+		{"only empty", []string{"", "NULL"}, "TEXT"},
+		{"no values", nil, "TEXT"},
+		{"0 and 1 are integers", []string{"0", "1"}, "INTEGER"},
+		{"invalid date", []string{"2024-13-45"}, "TEXT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferColumnType(tt.values); got != tt.want {
+				t.Fatalf("inferColumnType(%q) = %s, want %s", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferColumnTypes(t *testing.T) {
+	headers := []string{"id", "price", "active", "note"}
+	records := [][]string{
+		{"1", "9.99", "true", "first"},
+		{"2", "10", "false"}, // короткая строка: note отсутствует
+		{"3", "", "NULL", ""},
+	}
+	want := []string{"INTEGER", "FLOAT", "BOOLEAN", "TEXT"}
+	if got := inferColumnTypes(headers, records); !reflect.DeepEqual(got, want) {
+		t.Fatalf("inferColumnTypes() = %v, want %v", got, want)
+	}
+
+	// Смотрим только первые inferSampleSize строк
+	sample := make([][]string, 0, inferSampleSize+1)
+	for i := 0; i < inferSampleSize; i++ {
+		sample = append(sample, []string{"1"})
+	}
+	sample = append(sample, []string{"text"})
+	if got := inferColumnTypes([]string{"n"}, sample); got[0] != "INTEGER" {
+		t.Fatalf("значение после выборки повлияло на тип: %s", got[0])
+	}
+}
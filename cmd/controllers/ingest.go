@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+// ndjsonContentType — тип тела для потоковой загрузки строк
+const ndjsonContentType = "application/x-ndjson"
+
+// maxIngestFailures — сколько ошибочных строк возвращается в отчете, остальные только считаются
+const maxIngestFailures = 100
+
+// ingestBatch — строки NDJSON, которые вставляются одним INSERT
+type ingestBatch struct {
+	rows  []map[string]interface{}
+	lines []int  // номера строк NDJSON для отчета об ошибках
+	key   string // набор колонок: в одном INSERT у всех строк он одинаковый
+}
+
+// ingestReport — итог потоковой загрузки
+type ingestReport struct {
+	Lines       int        `json:"lines"`
+	Inserted    int        `json:"inserted"`
+	FailedCount int        `json:"failedCount"`
+	Failed      []rowError `json:"failed"`
+}
+
+func (r *ingestReport) fail(line int, field, message string) {
+	r.FailedCount++
+	if len(r.Failed) < maxIngestFailures {
+		r.Failed = append(r.Failed, rowError{Row: line, Field: field, Error: message})
+	}
+}
+
+// rowColumnsKey возвращает отсортированный список колонок строки одной строкой
+func rowColumnsKey(row map[string]interface{}) string {
+	keys := make([]string, 0, len(row))
+	for k := range row {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// IngestRows потоково вставляет строки из тела application/x-ndjson (один JSON-объект на строку).
+// Строки вставляются пачками по INGEST_BATCH_SIZE по мере чтения, поэтому память не зависит
+// от размера тела. Каждая пачка фиксируется отдельно; если INSERT пачки не удался, ее строки
+// вставляются по одной, чтобы в отчет попали только действительно ошибочные строки (номера с 1)
+func IngestRows(c *gin.Context) {
+	tableName := c.Param("name")
+	cfg := initializers.GetConfig()
+
+	if c.ContentType() != ndjsonContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":    "Ожидается тело " + ndjsonContentType,
+			"received": c.ContentType(),
+		})
+		return
+	}
+
+	// Колонки и их типы проверяем один раз до чтения тела
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(columnTypes) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		types[col.ColumnName] = col.DataType
+	}
+
+	var report ingestReport
+	batch := ingestBatch{}
+
+	// Пачки фиксируются по мере чтения, поэтому итог пишется в журнал и при прерванной загрузке
+	defer func() {
+		if report.Inserted > 0 {
+			logTableChange(getDB(c), c, tableName, "ingest", "", map[string]interface{}{
+				"inserted":    report.Inserted,
+				"failedCount": report.FailedCount,
+			})
+		}
+	}()
+
+	flush := func() error {
+		if len(batch.rows) == 0 {
+			return nil
+		}
+		defer func() { batch = ingestBatch{} }()
+
+		err := getDB(c).Table(tableName).CreateInBatches(batch.rows, len(batch.rows)).Error
+		if err == nil {
+			report.Inserted += len(batch.rows)
+			return nil
+		}
+		if isQueryCanceled(err) {
+			return err
+		}
+
+		for i, row := range batch.rows {
+			if err := getDB(c).Table(tableName).Create(row).Error; err != nil {
+				if isQueryCanceled(err) {
+					return err
+				}
+				apiErr := dbWriteError(err)
+				column, _ := apiErr.Body["column"].(string)
+				message := fmt.Sprint(apiErr.Body["error"])
+				if details, ok := apiErr.Body["details"]; ok {
+					message += ": " + fmt.Sprint(details)
+				}
+				report.fail(batch.lines[i], column, message)
+				continue
+			}
+			report.Inserted++
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), cfg.IngestMaxLine)
+	for scanner.Scan() {
+		report.Lines++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var row map[string]interface{}
+		if err := json.Unmarshal(line, &row); err != nil {
+			report.fail(report.Lines, "", "некорректный JSON: "+err.Error())
+			continue
+		}
+		if len(row) == 0 {
+			report.fail(report.Lines, "", "пустой объект")
+			continue
+		}
+		coerced, field, err := coerceRow(row, types)
+		if err != nil {
+			report.fail(report.Lines, field, err.Error())
+			continue
+		}
+		if errs := validateFields(tableName, row); len(errs) > 0 {
+			report.fail(report.Lines, errs[0].Field, errs[0].Error)
+			continue
+		}
+
+		// Отсутствующая колонка должна получить DEFAULT, а не NULL, поэтому
+		// строки с другим набором колонок начинают новую пачку
+		key := rowColumnsKey(coerced)
+		if len(batch.rows) > 0 && (batch.key != key || len(batch.rows) >= cfg.IngestBatchSize) {
+			if err := flush(); err != nil {
+				respondIngestError(c, err, report)
+				return
+			}
+		}
+		batch.key = key
+		batch.rows = append(batch.rows, coerced)
+		batch.lines = append(batch.lines, report.Lines)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("строка %d длиннее INGEST_MAX_LINE_SIZE (%d байт)", report.Lines+1, cfg.IngestMaxLine)
+		}
+		respondIngestError(c, newAPIError(http.StatusBadRequest, "Ошибка чтения тела запроса", err), report)
+		return
+	}
+	if err := flush(); err != nil {
+		respondIngestError(c, err, report)
+		return
+	}
+
+	status := http.StatusOK
+	if report.Inserted == 0 && report.FailedCount > 0 {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{
+		"status":      "Загрузка завершена",
+		"lines":       report.Lines,
+		"inserted":    report.Inserted,
+		"failedCount": report.FailedCount,
+		"failed":      report.Failed,
+	})
+}
+
+// respondIngestError прерывает загрузку и сообщает, сколько строк уже вставлено:
+// пачки фиксируются по мере чтения и не откатываются
+func respondIngestError(c *gin.Context, err error, report ingestReport) {
+	apiErr, ok := err.(*apiError)
+	if !ok {
+		apiErr = newAPIError(http.StatusInternalServerError, "Ошибка загрузки строк", err)
+		if isQueryCanceled(err) {
+			apiErr = newAPIError(http.StatusGatewayTimeout, "Превышено время выполнения запроса к БД", err)
+		}
+	}
+	apiErr.Body["report"] = report
+	c.JSON(apiErr.Status, apiErr.Body)
+}
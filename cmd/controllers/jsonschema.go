@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// schemaColumn — сведения о колонке для построения JSON Schema
+type schemaColumn struct {
+	ColumnName    string  `gorm:"column:column_name"`
+	DataType      string  `gorm:"column:data_type"`
+	IsNullable    string  `gorm:"column:is_nullable"`
+	ColumnDefault *string `gorm:"column:column_default"`
+	IsIdentity    string  `gorm:"column:is_identity"`
+}
+
+// isSerial проверяет, что колонка заполняется автоматически (SERIAL или IDENTITY)
+func (col schemaColumn) isSerial() bool {
+	return col.IsIdentity == "YES" ||
+		(col.ColumnDefault != nil && strings.HasPrefix(*col.ColumnDefault, "nextval("))
+}
+
+// jsonSchemaProperty сопоставляет тип Postgres с типом JSON Schema
+func jsonSchemaProperty(dataType string) gin.H {
+	switch {
+	case isIntegerType(dataType):
+		return gin.H{"type": "integer"}
+	case isFloatType(dataType):
+		return gin.H{"type": "number"}
+	case dataType == "boolean":
+		return gin.H{"type": "boolean"}
+	case dataType == "date":
+		return gin.H{"type": "string", "format": "date"}
+	case dataType == "time without time zone" || dataType == "time with time zone":
+		return gin.H{"type": "string", "format": "time"}
+	case isTimeType(dataType):
+		return gin.H{"type": "string", "format": "date-time"}
+	case dataType == "uuid":
+		return gin.H{"type": "string", "format": "uuid"}
+	case isJSONType(dataType):
+		return gin.H{}
+	}
+	return gin.H{"type": "string"}
+}
+
+// GetTableJSONSchema описывает таблицу в виде JSON Schema. Обязательными считаются
+// NOT NULL колонки, кроме автоинкрементных
+func GetTableJSONSchema(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var columns []schemaColumn
+	if err := getDB(c).Raw(`
+		SELECT column_name, data_type, is_nullable, column_default, is_identity
+		FROM information_schema.columns
+		WHERE table_name = ?
+		ORDER BY ordinal_position
+	`, tableName).Scan(&columns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(columns) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+
+	properties := gin.H{}
+	required := []string{}
+	for _, col := range columns {
+		property := jsonSchemaProperty(col.DataType)
+		if col.IsNullable == "YES" {
+			if t, ok := property["type"]; ok {
+				property["type"] = []interface{}{t, "null"}
+			}
+		} else if !col.isSerial() {
+			required = append(required, col.ColumnName)
+		}
+		properties[col.ColumnName] = property
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                tableName,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	})
+}
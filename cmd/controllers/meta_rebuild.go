@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// serialTypes — целочисленные типы, которые с nextval() по умолчанию записываются как SERIAL
+var serialTypes = map[string]string{
+	"smallint": "SMALLSERIAL",
+	"integer":  "SERIAL",
+	"bigint":   "BIGSERIAL",
+}
+
+// liveColumnSpecs строит описания колонок "name:type[:pk][:default=...]" по текущей схеме таблицы.
+// Колонки created_at/updated_at пропускаются при timestamps, как и в CreateTable
+func liveColumnSpecs(db *gorm.DB, tableName string, timestamps bool) ([]string, error) {
+	var columns []ddlColumn
+	if err := db.Raw(`
+		SELECT column_name, data_type, udt_name, character_maximum_length, is_nullable, column_default
+		FROM information_schema.columns
+		WHERE table_name = ?
+		ORDER BY ordinal_position
+	`, tableName).Scan(&columns).Error; err != nil {
+		return nil, err
+	}
+
+	// Модификатор pk есть только у простого ключа: составной CreateTable не принимает
+	var pkColumn string
+	if pk, err := getPrimaryKeyColumns(db, tableName); err == nil && len(pk) == 1 {
+		pkColumn = pk[0]
+	}
+
+	specs := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if timestamps && (col.ColumnName == "created_at" || col.ColumnName == "updated_at") {
+			continue
+		}
+
+		colType := col.sqlType()
+		serial := false
+		if col.ColumnDefault != nil && nextvalPattern.MatchString(*col.ColumnDefault) {
+			if t, ok := serialTypes[col.DataType]; ok {
+				colType, serial = t, true
+			}
+		}
+
+		spec := col.ColumnName + ":" + colType
+		if col.ColumnName == pkColumn {
+			spec += ":pk"
+		}
+		// Сохраняем только значения по умолчанию, которые принял бы parseColumnSpec
+		if col.ColumnDefault != nil && !serial {
+			if parsed, err := parseColumnSpec(spec + ":default=" + *col.ColumnDefault); err == nil && parsed.Default != "" {
+				spec += ":default=" + *col.ColumnDefault
+			}
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// RebuildTableMeta пересобирает TableMeta.Columns по текущей схеме таблицы.
+// Нужен для таблиц, метаданные которых разошлись со схемой после ALTER
+func RebuildTableMeta(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var meta model.TableMeta
+	var previous, columns []string
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if err := lockTableSchema(tx, tableName); err != nil {
+			return err
+		}
+
+		if err := tx.Where("name = ?", tableName).First(&meta).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				apiErr := newAPIError(http.StatusNotFound, "Метаданные таблицы не найдены", nil)
+				apiErr.Body["hint"] = "Метаданные есть только у таблиц, созданных через API"
+				return apiErr
+			}
+			return err
+		}
+
+		exists, err := tableExists(tx, tableName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			apiErr := tableNotFound(tableName)
+			apiErr.Body["hint"] = "Удалите устаревшие метаданные через DELETE /api/tables/orphans"
+			return apiErr
+		}
+
+		json.Unmarshal([]byte(meta.Columns), &previous)
+		if columns, err = liveColumnSpecs(tx, tableName, meta.Timestamps); err != nil {
+			return err
+		}
+
+		columnsJSON, _ := json.Marshal(columns)
+		meta.Columns = string(columnsJSON)
+		return tx.Save(&meta).Error
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "Метаданные таблицы пересобраны",
+		"table":    tableName,
+		"columns":  columns,
+		"previous": previous,
+	})
+}
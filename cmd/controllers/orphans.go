@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// serviceModels — служебные таблицы приложения, у которых не бывает TableMeta
+var serviceModels = []interface{}{
+	&model.TableMeta{}, &model.SavedQuery{}, &model.AccessLog{}, &model.AuditLog{}, &model.IdempotencyKey{},
+}
+
+// orphanRow — строка результата поиска: метаданные без таблицы или таблица без метаданных
+type orphanRow struct {
+	MetaID    *uint   `gorm:"column:meta_id"`
+	MetaName  *string `gorm:"column:meta_name"`
+	TableName *string `gorm:"column:table_name"`
+}
+
+// modelTableName возвращает имя таблицы модели GORM
+func modelTableName(db *gorm.DB, value interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(value); err != nil {
+		return "", err
+	}
+	return stmt.Schema.Table, nil
+}
+
+// findOrphans одним FULL JOIN с information_schema находит записи TableMeta без таблицы
+// и таблицы схемы public без TableMeta (кроме служебных)
+func findOrphans(db *gorm.DB) (staleMeta []orphanRow, unmanaged []string, err error) {
+	metaTable, err := modelTableName(db, &model.TableMeta{})
+	if err != nil {
+		return nil, nil, err
+	}
+	service := make([]string, 0, len(serviceModels))
+	for _, m := range serviceModels {
+		name, err := modelTableName(db, m)
+		if err != nil {
+			return nil, nil, err
+		}
+		service = append(service, name)
+	}
+
+	var rows []orphanRow
+	if err := db.Raw(fmt.Sprintf(`
+		SELECT m.id AS meta_id, m.name AS meta_name, t.table_name
+		FROM %s m
+		FULL OUTER JOIN (
+			SELECT table_name
+			FROM information_schema.tables
+			WHERE table_schema = 'public' AND table_type = 'BASE TABLE'
+			AND table_name NOT IN ?
+		) t ON t.table_name = m.name
+		WHERE m.id IS NULL OR t.table_name IS NULL
+		ORDER BY COALESCE(m.name, t.table_name)
+	`, quoteIdentifier(metaTable)), service).Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	staleMeta = []orphanRow{}
+	unmanaged = []string{}
+	for _, row := range rows {
+		if row.TableName == nil {
+			staleMeta = append(staleMeta, row)
+		} else {
+			unmanaged = append(unmanaged, *row.TableName)
+		}
+	}
+	return staleMeta, unmanaged, nil
+}
+
+// GetOrphans показывает рассинхронизацию TableMeta и таблиц БД, ничего не меняя
+func GetOrphans(c *gin.Context) {
+	staleMeta, unmanaged, err := findOrphans(getDB(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	names := make([]string, len(staleMeta))
+	for i, row := range staleMeta {
+		names[i] = *row.MetaName
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"metaWithoutTable":  names,
+		"tablesWithoutMeta": unmanaged,
+	})
+}
+
+// DeleteOrphans удаляет записи TableMeta, для которых нет таблицы.
+// Таблицы без метаданных не трогаются
+func DeleteOrphans(c *gin.Context) {
+	deleted := []string{}
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		staleMeta, _, err := findOrphans(tx)
+		if err != nil {
+			return err
+		}
+		if len(staleMeta) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(staleMeta))
+		for i, row := range staleMeta {
+			ids[i] = *row.MetaID
+			deleted = append(deleted, *row.MetaName)
+		}
+		return tx.Delete(&model.TableMeta{}, ids).Error
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "Устаревшие метаданные удалены",
+		"deleted": len(deleted),
+		"tables":  deleted,
+	})
+}
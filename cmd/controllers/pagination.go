@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// pagination — параметры страницы из ?page=&pageSize=
+type pagination struct {
+	Page     int
+	PageSize int
+}
+
+func (p pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// getPagination возвращает параметры страницы, если клиент запросил ?paginate=true.
+// Без флага списки отдаются целиком, как и раньше
+func getPagination(c *gin.Context) (pagination, bool) {
+	if c.Query("paginate") != "true" {
+		return pagination{}, false
+	}
+	return parsePagination(c), true
+}
+
+// parsePagination читает ?page=&pageSize= без флага paginate — для списков,
+// которые отдаются только постранично
+func parsePagination(c *gin.Context) pagination {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return pagination{Page: page, PageSize: pageSize}
+}
+
+// paginatedResponse оборачивает страницу данных в конверт {data, page, pageSize, total}
+func paginatedResponse(data interface{}, p pagination, total int64) gin.H {
+	return gin.H{
+		"data":     data,
+		"page":     p.Page,
+		"pageSize": p.PageSize,
+		"total":    total,
+	}
+}
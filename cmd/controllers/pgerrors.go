@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Коды ошибок Postgres для нарушений ограничений
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgQueryCanceled       = "57014" // statement_timeout или отмена запроса
+	pgReadOnlyTransaction = "25006" // запись в транзакции только для чтения
+)
+
+// isQueryCanceled сообщает, что Postgres отменил запрос, например по statement_timeout
+func isQueryCanceled(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgQueryCanceled
+}
+
+// isReadOnlyViolation сообщает, что Postgres отклонил запись в транзакции только для чтения
+func isReadOnlyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgReadOnlyTransaction
+}
+
+// readOnlyError — ответ 403 на запрос, изменяющий данные, в режиме только для чтения
+func readOnlyError(cause error) *apiError {
+	return newAPIError(http.StatusForbidden, "В режиме только для чтения разрешены только SELECT", cause)
+}
+
+// dbWriteError переводит ошибку записи в apiError: нарушение уникальности и внешнего ключа — 409,
+// NULL в NOT NULL колонке — 400, остальные ошибки — 500
+func dbWriteError(err error) *apiError {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return newAPIError(http.StatusInternalServerError, "Ошибка записи данных", err)
+	}
+
+	var apiErr *apiError
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		apiErr = newAPIError(http.StatusConflict,
+			fmt.Sprintf("Значение нарушает ограничение уникальности %s", pgErr.ConstraintName), nil)
+	case pgForeignKeyViolation:
+		apiErr = newAPIError(http.StatusConflict,
+			fmt.Sprintf("Нарушен внешний ключ %s таблицы %s", pgErr.ConstraintName, pgErr.TableName), nil)
+		apiErr.Body["table"] = pgErr.TableName
+	case pgNotNullViolation:
+		apiErr = newAPIError(http.StatusBadRequest,
+			fmt.Sprintf("Колонка %s не может быть NULL", pgErr.ColumnName), nil)
+		apiErr.Body["column"] = pgErr.ColumnName
+	default:
+		return newAPIError(http.StatusInternalServerError, "Ошибка записи данных", err)
+	}
+
+	if pgErr.ConstraintName != "" {
+		apiErr.Body["constraint"] = pgErr.ConstraintName
+	}
+	if pgErr.Detail != "" {
+		apiErr.Body["details"] = pgErr.Detail
+	}
+	return apiErr
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errNoPrimaryKey — у таблицы нет первичного ключа (возможно при REQUIRE_PRIMARY_KEY=false
+// или для таблиц, созданных не через API)
+var errNoPrimaryKey = errors.New("у таблицы нет первичного ключа")
+
+// noPrimaryKeyNotice — предупреждение в ответе CreateTable для таблицы без ключа
+const noPrimaryKeyNotice = "Таблица создана без первичного ключа: операции с отдельными строками для нее недоступны"
+
+// primaryKeyLookupError отличает отсутствие ключа от ошибки запроса к каталогу
+func primaryKeyLookupError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNoPrimaryKey
+	}
+	return fmt.Errorf("не удалось определить первичный ключ: %v", err)
+}
+
+// respondPrimaryKeyError отвечает на ошибку поиска первичного ключа: для таблицы без ключа —
+// 409 с подсказкой, для остальных ошибок — 500
+func respondPrimaryKeyError(c *gin.Context, err error) {
+	if errors.Is(err, errNoPrimaryKey) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "У таблицы нет первичного ключа: операции с отдельными строками недоступны",
+			"hint":  "Добавьте первичный ключ (ALTER TABLE ... ADD PRIMARY KEY) или работайте с таблицей целиком",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
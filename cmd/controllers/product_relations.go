@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// Связи продукта с поставками и материалами: /api/products/:id/deliveries и /materials
+var (
+	ListProductDeliveries = listProductRelated[model.Delivery]("Deliveries")
+	AddProductDeliveries  = addProductRelated[model.Delivery]("Deliveries")
+	RemoveProductDelivery = removeProductRelated[model.Delivery]("Deliveries")
+	ListProductMaterials  = listProductRelated[model.Material]("Materials")
+	AddProductMaterials   = addProductRelated[model.Material]("Materials")
+	RemoveProductMaterial = removeProductRelated[model.Material]("Materials")
+)
+
+// loadProduct находит продукт по :id и отвечает 400/404, если не получилось
+func loadProduct(c *gin.Context, db *gorm.DB) (*model.Product, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный id продукта", "received": c.Param("id")})
+		return nil, false
+	}
+
+	var product model.Product
+	if err := db.First(&product, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Продукт не найден", "id": id})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	return &product, true
+}
+
+// primaryKeyOf возвращает колонку первичного ключа модели T
+func primaryKeyOf[T any](db *gorm.DB) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return "", err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return "", fmt.Errorf("у модели %s нет первичного ключа", stmt.Schema.Name)
+	}
+	return stmt.Schema.PrioritizedPrimaryField.DBName, nil
+}
+
+// listProductRelated отдает связанные с продуктом записи целиком
+func listProductRelated[T any](association string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := getDB(c)
+		product, ok := loadProduct(c, db)
+		if !ok {
+			return
+		}
+
+		var items []T
+		if err := db.Model(product).Association(association).Find(&items); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+// addProductRelated связывает продукт с записями из {"ids": [...]}. Все id должны существовать,
+// уже существующие связи не дублируются. Возвращает полный список связанных записей
+func addProductRelated[T any](association string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			IDs []int `json:"ids" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(req.IDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Не указаны id для связи"})
+			return
+		}
+
+		db := getDB(c)
+		product, ok := loadProduct(c, db)
+		if !ok {
+			return
+		}
+
+		pk, err := primaryKeyOf[T](db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var items []T
+		if err := db.Where(fmt.Sprintf("%s IN ?", quoteIdentifier(pk)), req.IDs).Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var found []int
+		if err := db.Model(new(T)).Where(fmt.Sprintf("%s IN ?", quoteIdentifier(pk)), req.IDs).Pluck(pk, &found).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		exists := make(map[int]bool, len(found))
+		for _, id := range found {
+			exists[id] = true
+		}
+		var missing []int
+		for _, id := range req.IDs {
+			if !exists[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Записи не найдены", "ids": missing})
+			return
+		}
+
+		var related []T
+		ok = WithTransaction(c, func(tx *gorm.DB) error {
+			assoc := tx.Model(product).Association(association)
+			if err := assoc.Append(&items); err != nil {
+				return dbWriteError(err)
+			}
+			return assoc.Find(&related)
+		})
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, related)
+	}
+}
+
+// removeProductRelated удаляет связь продукта с записью :relatedId; сама запись остается
+func removeProductRelated[T any](association string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := getDB(c)
+		product, ok := loadProduct(c, db)
+		if !ok {
+			return
+		}
+
+		relatedID, err := strconv.Atoi(c.Param("relatedId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный id", "received": c.Param("relatedId")})
+			return
+		}
+
+		var item T
+		if err := db.First(&item, relatedID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Запись не найдена", "id": relatedID})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		if err := db.Model(product).Association(association).Delete(&item); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "Связь удалена", "id": relatedID})
+	}
+}
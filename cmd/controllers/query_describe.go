@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resultColumn — описание колонки результата запроса
+type resultColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable *bool  `json:"nullable"`
+}
+
+// DescribeQuery возвращает колонки, которые вернет SELECT, не выбирая строк:
+// запрос оборачивается в LIMIT 0 и выполняется в откатываемой транзакции.
+// nullable равен null, если драйвер не знает, допускает ли колонка NULL
+func DescribeQuery(c *gin.Context) {
+	var req struct {
+		Query string `json:"query" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := trimStatement(req.Query)
+	if !isSelectQuery(query) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Описать можно только одиночный SELECT",
+			"kind":  statementKind(query),
+		})
+		return
+	}
+
+	tx := beginTx(c)
+	defer tx.Rollback()
+
+	rows, err := tx.Raw(fmt.Sprintf("SELECT * FROM (%s) AS q LIMIT 0", query)).Rows()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ошибка выполнения запроса",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	columns := make([]resultColumn, 0, len(columnTypes))
+	for _, ct := range columnTypes {
+		col := resultColumn{Name: ct.Name(), Type: ct.DatabaseTypeName()}
+		if nullable, ok := ct.Nullable(); ok {
+			col.Nullable = &nullable
+		}
+		columns = append(columns, col)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"columns": columns})
+}
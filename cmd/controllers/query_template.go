@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bareIdentifierPattern — имена, которые Postgres понимает без кавычек и не приводит к другому регистру
+var bareIdentifierPattern = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// defaultTemplateLimit — LIMIT в шаблоне SELECT
+const defaultTemplateLimit = 100
+
+// sqlIdentifier возвращает имя для вставки в SQL: в кавычках, только если без них имя
+// изменится или сломает запрос (заглавные буквы, спецсимволы, зарезервированные слова).
+// С quoteAll кавычки ставятся всегда
+func sqlIdentifier(name string, quoteAll bool) string {
+	if !quoteAll && bareIdentifierPattern.MatchString(name) && !isReservedWord(name) {
+		return name
+	}
+	return quoteIdentifier(name)
+}
+
+// GetQueryTemplate возвращает готовые к копированию SELECT-запросы к таблице
+// с правильно экранированными именами — для поля запроса ExecuteQuery.
+// ?quote=all ставит кавычки у всех имен, ?limit= задает LIMIT (по умолчанию 100)
+func GetQueryTemplate(c *gin.Context) {
+	tableName := c.Param("name")
+	quoteAll := c.Query("quote") == "all"
+
+	limit := defaultTemplateLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("некорректный limit '%s', допустимо от 1 до %d", raw, maxPageSize)})
+			return
+		}
+		limit = n
+	}
+
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(columnTypes) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+
+	names := make([]string, 0, len(columnTypes))
+	for _, col := range columnTypes {
+		names = append(names, col.ColumnName)
+	}
+	names = applyColumnOrder(names, getColumnOrder(getDB(c), tableName))
+
+	table := sqlIdentifier(tableName, quoteAll)
+	needsQuoting := table != tableName
+	identifiers := make([]string, len(names))
+	columns := make([]gin.H, len(names))
+	for i, name := range names {
+		identifiers[i] = sqlIdentifier(name, quoteAll)
+		needsQuoting = needsQuoting || identifiers[i] != name
+		columns[i] = gin.H{"name": name, "identifier": identifiers[i]}
+	}
+
+	selectList := strings.Join(identifiers, ", ")
+	response := gin.H{
+		"table":        tableName,
+		"identifier":   table,
+		"needsQuoting": needsQuoting,
+		"columns":      columns,
+		"select":       fmt.Sprintf("SELECT %s\nFROM %s\nLIMIT %d", selectList, table, limit),
+		"count":        fmt.Sprintf("SELECT count(*)\nFROM %s", table),
+	}
+
+	// Запрос одной строки по ключу; значения передаются в args ExecuteQuery
+	if pkColumns, err := getPrimaryKeyColumns(getDB(c), tableName); err == nil {
+		conditions := make([]string, len(pkColumns))
+		for i, col := range pkColumns {
+			conditions[i] = sqlIdentifier(col, quoteAll) + " = ?"
+		}
+		response["selectByKey"] = fmt.Sprintf("SELECT %s\nFROM %s\nWHERE %s", selectList, table, strings.Join(conditions, " AND "))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
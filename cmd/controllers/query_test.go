@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"server/cmd/middleware"
+	"server/model"
+)
+
+// TestRunQueryReadOnlyMode проверяет, что при READ_ONLY_MODE=true выполнение запроса
+// отклоняет все, кроме SELECT, так же как проверка и импорт SQL
+func TestRunQueryReadOnlyMode(t *testing.T) {
+	setTestEnv(t, map[string]string{"READ_ONLY_MODE": "true"})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/execute", func(c *gin.Context) {
+		var req struct {
+			Query string `json:"query"`
+		}
+		c.ShouldBindJSON(&req)
+		runQuery(c, req.Query, nil, model.SavedQuery{})
+	})
+
+	queries := []string{
+		"UPDATE users SET name = 'x'",
+		"DELETE FROM users",
+		"SELECT 1; DROP TABLE users",
+		"CREATE TABLE t (id int)",
+	}
+	for _, query := range queries {
+		status, resp := doJSON(t, r, http.MethodPost, "/execute", gin.H{"query": query})
+		if status != http.StatusForbidden {
+			t.Errorf("%q: %d %v, want 403", query, status, resp)
+		}
+	}
+}
+
+// TestIsSingleStatement проверяет, что ';' в комментариях, литералах и идентификаторах
+// в кавычках не считается разделителем запросов
+func TestIsSingleStatement(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT 1;":                           true,
+		"SELECT ';' AS sep":                   true,
+		`SELECT 1 AS "a;b"`:                   true,
+		"SELECT 1 -- ; DROP TABLE users":      true,
+		"SELECT /* ; */ 1":                    true,
+		"SELECT $$;$$":                        true,
+		"SELECT 1; DROP TABLE users":          false,
+		`SELECT 1 AS "a"; DROP TABLE users`:   false,
+		"SELECT 1 /* x */; DELETE FROM users": false,
+	}
+	for query, want := range tests {
+		if got := isSingleStatement(query); got != want {
+			t.Errorf("isSingleStatement(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+// TestRunQueryReadOnlyTransaction проверяет, что запись, которую не видно по первому
+// ключевому слову, в режиме только для чтения отклоняет Postgres, а таблица не меняется
+func TestRunQueryReadOnlyTransaction(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_read_only")
+	dropTestTable(t, db, "test_read_only_copy")
+	if err := db.Exec("CREATE TABLE test_read_only (id int PRIMARY KEY)").Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("INSERT INTO test_read_only VALUES (1), (2)")
+	setTestEnv(t, map[string]string{"READ_ONLY_MODE": "true"})
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.POST("/api/queries/execute", ExecuteQuery)
+	})
+
+	queries := []string{
+		"WITH d AS (DELETE FROM test_read_only RETURNING *) SELECT * FROM d",
+		"SELECT * INTO test_read_only_copy FROM test_read_only",
+	}
+	for _, query := range queries {
+		status, resp := doJSON(t, r, http.MethodPost, "/api/queries/execute", gin.H{"query": query})
+		if status != http.StatusForbidden {
+			t.Errorf("%q: %d %v, want 403", query, status, resp)
+		}
+	}
+
+	var count int64
+	db.Table("test_read_only").Count(&count)
+	if count != 2 {
+		t.Fatalf("строк в таблице: %d, want 2", count)
+	}
+	if exists, _ := tableExists(db, "test_read_only_copy"); exists {
+		t.Fatal("SELECT INTO создал таблицу в режиме только для чтения")
+	}
+
+	status, resp := doJSON(t, r, http.MethodPost, "/api/queries/execute", gin.H{"query": "SELECT * FROM test_read_only"})
+	if status != http.StatusOK {
+		t.Fatalf("SELECT: %d %v", status, resp)
+	}
+}
+
+// TestExecuteQueryStatementTimeout проверяет, что медленный запрос прерывается по
+// X-Statement-Timeout и возвращает 504, а следующий запрос выполняется как обычно
+func TestExecuteQueryStatementTimeout(t *testing.T) {
+	testDB(t)
+	r := newTestRouter(func(r *gin.Engine) {
+		r.POST("/api/queries/execute", ExecuteQuery)
+	})
+
+	execute := func(query, timeout string) (int, map[string]interface{}) {
+		body, _ := json.Marshal(gin.H{"query": query})
+		req := httptest.NewRequest(http.MethodPost, "/api/queries/execute", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if timeout != "" {
+			req.Header.Set(middleware.StatementTimeoutHeader, timeout)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	start := time.Now()
+	status, resp := execute("SELECT pg_sleep(5)", "200")
+	if status != http.StatusGatewayTimeout {
+		t.Fatalf("медленный запрос: %d %v, want 504", status, resp)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("запрос прерван через %v, want около 200ms", elapsed)
+	}
+
+	if status, resp := execute("SELECT 1 AS one", ""); status != http.StatusOK {
+		t.Fatalf("запрос после таймаута: %d %v", status, resp)
+	}
+	if status, resp := execute("SELECT 1", "abc"); status != http.StatusBadRequest {
+		t.Fatalf("некорректный заголовок: %d %v, want 400", status, resp)
+	}
+}
+
+func TestNumberPlaceholders(t *testing.T) {
+	tests := []struct {
+		query, want string
+		count       int
+	}{
+		{"SELECT 1", "SELECT 1", 0},
+		{"SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = $1 AND b = $2", 2},
+		{"SELECT '?', \"?\" FROM t -- ?\nWHERE a = ?", "SELECT '?', \"?\" FROM t -- ?\nWHERE a = $1", 1},
+		{"UPDATE t SET name = 'я?' /* ? */ WHERE id = ?", "UPDATE t SET name = 'я?' /* ? */ WHERE id = $1", 1},
+	}
+	for _, tt := range tests {
+		got, count, origin := numberPlaceholders(tt.query)
+		if got != tt.want || count != tt.count {
+			t.Errorf("numberPlaceholders(%q) = %q, %d, want %q, %d", tt.query, got, count, tt.want, tt.count)
+		}
+		if len(origin) != len([]rune(got)) {
+			t.Errorf("%q: позиций %d, want %d", tt.query, len(origin), len([]rune(got)))
+		}
+	}
+
+	// Символ после $1 соответствует символу после ? в исходном запросе
+	_, _, origin := numberPlaceholders("a = ? b")
+	if origin[6] != 5 {
+		t.Fatalf("позиция символа после $1: %d, want 5", origin[6])
+	}
+}
+
+// TestValidateQueryUnsupportedKind проверяет, что DDL не проверяется через EXPLAIN,
+// а получает явный ответ о неподдерживаемом запросе
+func TestValidateQueryUnsupportedKind(t *testing.T) {
+	setTestEnv(t, nil)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/queries/validate", ValidateQuery)
+
+	for _, query := range []string{"CREATE TABLE t (id int)", "DROP TABLE t", "VACUUM t"} {
+		status, resp := doJSON(t, r, http.MethodPost, "/api/queries/validate", gin.H{"query": query})
+		if status != http.StatusBadRequest || resp["valid"] != false || resp["kind"] == "" {
+			t.Errorf("%q: %d %v, want 400 с kind", query, status, resp)
+		}
+	}
+}
+
+// TestValidateQueryPlaceholders проверяет запросы с плейсхолдерами ?: корректный
+// возвращает типы параметров, ошибка указывает позицию в исходном запросе
+func TestValidateQueryPlaceholders(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_validate")
+	if err := db.Exec("CREATE TABLE test_validate (id int PRIMARY KEY, name text)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(func(r *gin.Engine) {
+		r.POST("/api/queries/validate", ValidateQuery)
+	})
+
+	// Дважды подряд: подготовленный запрос должен удаляться после проверки
+	for run := 1; run <= 2; run++ {
+		status, resp := doJSON(t, r, http.MethodPost, "/api/queries/validate",
+			gin.H{"query": "UPDATE test_validate SET name = ? WHERE id = ?"})
+		types, _ := resp["paramTypes"].([]interface{})
+		if status != http.StatusOK || resp["valid"] != true || len(types) != 2 || types[0] != "text" || types[1] != "integer" {
+			t.Fatalf("запуск %d: %d %v, want valid с paramTypes [text integer]", run, status, resp)
+		}
+	}
+
+	status, resp := doJSON(t, r, http.MethodPost, "/api/queries/validate",
+		gin.H{"query": "SELECT missing FROM test_validate WHERE id = ?"})
+	if status != http.StatusOK || resp["valid"] != false || resp["column"] != 8.0 {
+		t.Fatalf("ошибка: %d %v, want valid=false, column 8", status, resp)
+	}
+}
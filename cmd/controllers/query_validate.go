@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"server/initializers"
+)
+
+// Префиксы, добавляемые к запросу при проверке; позиции ошибок сдвигаются на их длину.
+// Запрос с плейсхолдерами ? проверяется через PREPARE: EXPLAIN не принимает параметры
+const (
+	explainPrefix = "EXPLAIN "
+	preparePrefix = "PREPARE validate_query AS "
+)
+
+// validatableKinds — запросы, которые можно проверить через EXPLAIN или PREPARE, не выполняя.
+// DDL и служебные команды так не проверить: EXPLAIN ответил бы на них синтаксической ошибкой
+var validatableKinds = map[string]bool{
+	"SELECT": true, "WITH": true, "VALUES": true, "TABLE": true,
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+}
+
+// errorPosition переводит позицию символа в запросе (с 1) в номер строки и колонки
+func errorPosition(query string, position int) (int, int) {
+	line, column := 1, 1
+	for i, r := range []rune(query) {
+		if i >= position-1 {
+			break
+		}
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
+
+// ValidateQuery проверяет синтаксис запроса и существование объектов через EXPLAIN
+// в откатываемой транзакции, не выполняя сам запрос. Запрос с плейсхолдерами ? (как в
+// ExecuteQuery) проверяется через PREPARE/DEALLOCATE и возвращает типы параметров.
+// Проверяются только SELECT и DML, для остальных запросов — 400.
+// В режиме только для чтения (READ_ONLY_MODE=true) допускаются только SELECT.
+// Проверка выполняется в транзакции только для чтения
+func ValidateQuery(c *gin.Context) {
+	var req struct {
+		Query string `json:"query" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := trimStatement(req.Query)
+	if !isSingleStatement(query) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"valid": false,
+			"error": "Можно проверить только один запрос",
+		})
+		return
+	}
+
+	if initializers.GetConfig().ReadOnlyMode && !isSelectQuery(query) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"valid": false,
+			"error": "В режиме только для чтения разрешены только SELECT",
+			"kind":  statementKind(query),
+		})
+		return
+	}
+
+	kind := statementKind(query)
+	if !validatableKinds[kind] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"valid": false,
+			"error": fmt.Sprintf("Проверка не поддерживается для запросов %s", kind),
+			"kind":  kind,
+		})
+		return
+	}
+
+	// EXPLAIN без ANALYZE и PREPARE запрос не выполняют; транзакция только для чтения — дополнительная защита
+	tx, err := beginReadOnlyTx(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	numbered, params, origin := numberPlaceholders(query)
+	prefix := explainPrefix
+	if params > 0 {
+		prefix = preparePrefix
+		if err = tx.Exec(prefix + numbered).Error; err == nil {
+			// Подготовленный запрос живет до конца сессии, а не транзакции
+			var paramTypes []string
+			err = tx.Raw("SELECT unnest(parameter_types)::text FROM pg_prepared_statements WHERE name = 'validate_query'").
+				Scan(&paramTypes).Error
+			if deallocErr := tx.Exec("DEALLOCATE validate_query").Error; err == nil {
+				err = deallocErr
+			}
+			if err == nil {
+				c.JSON(http.StatusOK, gin.H{
+					"valid":      true,
+					"kind":       kind,
+					"params":     params,
+					"paramTypes": paramTypes,
+				})
+				return
+			}
+		}
+	} else {
+		var plan []string
+		if err = tx.Raw(prefix + numbered).Scan(&plan).Error; err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"valid": true,
+				"kind":  kind,
+				"plan":  strings.Join(plan, "\n"),
+			})
+			return
+		}
+	}
+
+	response := gin.H{
+		"valid": false,
+		"error": err.Error(),
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		response["error"] = pgErr.Message
+		response["code"] = pgErr.Code
+		if pgErr.Hint != "" {
+			response["hint"] = pgErr.Hint
+		}
+		// Позиция считается в символах запроса с префиксом и $1 вместо ?
+		if position := int(pgErr.Position) - len(prefix); position > 0 && position <= len(origin) {
+			position = origin[position-1] + 1
+			line, column := errorPosition(query, position)
+			response["position"] = position
+			response["line"] = line
+			response["column"] = column
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
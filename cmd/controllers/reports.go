@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/initializers"
+	"server/model"
+)
+
+// deliveryGroupings — допустимые значения ?groupBy= и соответствующие SQL-выражения
+var deliveryGroupings = map[string]string{
+	"product_type":   "product_type",
+	"address":        "address",
+	"employee_id":    "employee_id",
+	"transport_id":   "transport_id",
+	"sales_point_id": "sales_point_id",
+	"day":            "date_trunc('day', delivery_date)",
+	"month":          "date_trunc('month', delivery_date)",
+}
+
+// deliveryReportRow — итоги по одной группе поставок
+type deliveryReportRow struct {
+	Group         string  `gorm:"column:group_key" json:"group"`
+	TotalAmount   float64 `gorm:"column:total_amount" json:"totalAmount"`
+	TotalQuantity int64   `gorm:"column:total_quantity" json:"totalQuantity"`
+	Deliveries    int64   `gorm:"column:deliveries" json:"deliveries"`
+}
+
+// parseReportDate читает дату из параметра запроса; пустой параметр — нулевое время
+func parseReportDate(c *gin.Context, param string) (time.Time, error) {
+	raw := c.Query(param)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	value, err := coerceInput(raw, "date")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return value.(time.Time), nil
+}
+
+// DeliveriesReport суммирует amount и quantity поставок по ?groupBy= (по умолчанию product_type)
+// за период ?from=&to= по delivery_date. Границы включительные, любую можно опустить
+func DeliveriesReport(c *gin.Context) {
+	groupBy := c.DefaultQuery("groupBy", "product_type")
+	expr, ok := deliveryGroupings[groupBy]
+	if !ok {
+		allowed := make([]string, 0, len(deliveryGroupings))
+		for name := range deliveryGroupings {
+			allowed = append(allowed, name)
+		}
+		sort.Strings(allowed)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимый groupBy", "groupBy": groupBy, "allowed": allowed})
+		return
+	}
+
+	stmt := &gorm.Statement{DB: getDB(c)}
+	if err := stmt.Parse(&model.Delivery{}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !initializers.TableAllowed(stmt.Schema.Table) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Доступ к таблице запрещен", "table": stmt.Schema.Table})
+		return
+	}
+
+	from, err := parseReportDate(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "from"})
+		return
+	}
+	to, err := parseReportDate(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "to"})
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from должен быть не позже to"})
+		return
+	}
+
+	db := getDB(c).Model(&model.Delivery{})
+	if !from.IsZero() {
+		db = db.Where("delivery_date >= ?", from)
+	}
+	if !to.IsZero() {
+		db = db.Where("delivery_date <= ?", to)
+	}
+
+	rows := []deliveryReportRow{}
+	err = db.Select(fmt.Sprintf(`COALESCE(CAST(%s AS text), '') AS group_key,
+		COALESCE(SUM(amount), 0) AS total_amount,
+		COALESCE(SUM(quantity), 0) AS total_quantity,
+		COUNT(*) AS deliveries`, expr)).
+		Group("group_key").
+		Order("group_key").
+		Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"groupBy": groupBy,
+		"from":    c.Query("from"),
+		"to":      c.Query("to"),
+		"rows":    rows,
+	})
+}
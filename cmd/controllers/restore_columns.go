@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseColumnMap разбирает ?columnMap=csvCol:dbCol,csvCol2:dbCol2 (колонка CSV -> колонка таблицы)
+func parseColumnMap(raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	columnMap := map[string]string{}
+	used := map[string]string{}
+	for _, item := range strings.Split(raw, ",") {
+		from, to, ok := strings.Cut(item, ":")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("некорректный элемент columnMap '%s', ожидается csvCol:dbCol", item)
+		}
+		if other, dup := used[to]; dup && other != from {
+			return nil, fmt.Errorf("в колонку '%s' сопоставлены колонки CSV %s и %s", to, other, from)
+		}
+		columnMap[from] = to
+		used[to] = from
+	}
+	return columnMap, nil
+}
+
+// restoreColumnMapping — какие колонки CSV и в какие колонки таблицы восстанавливаются
+type restoreColumnMapping struct {
+	Targets []string // колонки таблицы для вставки, в порядке колонок CSV
+	Ignored []string // колонки CSV, которых нет в таблице
+	indices []int    // позиции Targets в строке CSV
+}
+
+// mapRestoreColumns сопоставляет колонки CSV с колонками таблицы по columnMap, иначе по имени.
+// Без partial любая несопоставленная колонка — ошибка
+func mapRestoreColumns(headers []string, columnMap map[string]string, known map[string]bool, partial bool) (restoreColumnMapping, error) {
+	for from, to := range columnMap {
+		if !known[to] {
+			return restoreColumnMapping{}, fmt.Errorf("колонка '%s' из columnMap не найдена в таблице", to)
+		}
+		found := false
+		for _, h := range headers {
+			if h == from {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return restoreColumnMapping{}, fmt.Errorf("колонка '%s' из columnMap не найдена в CSV", from)
+		}
+	}
+
+	m := restoreColumnMapping{Ignored: []string{}}
+	assigned := map[string]string{}
+	for i, h := range headers {
+		target := h
+		if mapped, ok := columnMap[h]; ok {
+			target = mapped
+		}
+		if !known[target] {
+			if !partial {
+				return restoreColumnMapping{}, fmt.Errorf("колонка '%s' не найдена в таблице", h)
+			}
+			m.Ignored = append(m.Ignored, h)
+			continue
+		}
+		if other, dup := assigned[target]; dup {
+			return restoreColumnMapping{}, fmt.Errorf("колонки CSV %s и %s попадают в одну колонку таблицы '%s'", other, h, target)
+		}
+		assigned[target] = h
+		m.Targets = append(m.Targets, target)
+		m.indices = append(m.indices, i)
+	}
+
+	if len(m.Targets) == 0 {
+		return restoreColumnMapping{}, fmt.Errorf("ни одна колонка CSV не сопоставлена с колонками таблицы")
+	}
+	return m, nil
+}
+
+// pick оставляет в строке CSV только значения сопоставленных колонок
+func (m restoreColumnMapping) pick(record []string) []string {
+	values := make([]string, len(m.indices))
+	for i, idx := range m.indices {
+		values[i] = record[idx]
+	}
+	return values
+}
+
+// defaulted возвращает колонки таблицы, не получившие значений из CSV: они заполняются DEFAULT или NULL
+func (m restoreColumnMapping) defaulted(tableColumns []string) []string {
+	filled := make(map[string]bool, len(m.Targets))
+	for _, t := range m.Targets {
+		filled[t] = true
+	}
+	result := []string{}
+	for _, col := range tableColumns {
+		if !filled[col] {
+			result = append(result, col)
+		}
+	}
+	return result
+}
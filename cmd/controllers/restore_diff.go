@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// diffSampleSize — сколько различий возвращается в примере
+const diffSampleSize = 20
+
+// rowDiff — пример различия между текущей строкой и строкой из CSV
+type rowDiff struct {
+	Type    string               `json:"type"` // "added", "removed", "changed"
+	Key     string               `json:"key"`
+	Changes map[string][2]string `json:"changes,omitempty"` // колонка -> [текущее, из файла]
+}
+
+// csvValuesEqual сравнивает значение из БД (в виде строки CSV) со значением из файла
+// с учетом записи чисел и дат
+func csvValuesEqual(current, incoming, dataType string) bool {
+	if incoming == "NULL" {
+		incoming = ""
+	}
+	if current == incoming {
+		return true
+	}
+
+	switch {
+	case isIntegerType(dataType) || isFloatType(dataType):
+		a, errA := strconv.ParseFloat(current, 64)
+		b, errB := strconv.ParseFloat(incoming, 64)
+		return errA == nil && errB == nil && a == b
+	case isTimeType(dataType):
+		a, errA := time.Parse(time.RFC3339, current)
+		if errA != nil {
+			return false
+		}
+		for _, layout := range timestampLayouts {
+			if b, err := time.Parse(layout, incoming); err == nil {
+				return a.Equal(b) || a.Format("2006-01-02 15:04:05") == b.Format("2006-01-02 15:04:05")
+			}
+		}
+	}
+	return false
+}
+
+// RestoreTableDiff сравнивает загруженный CSV с текущими строками таблицы по первичному ключу
+// и показывает, что изменит RestoreTable. Данные в БД не меняются
+func RestoreTableDiff(c *gin.Context) {
+	tableName := c.Param("name")
+
+	columnTypes, err := getColumnTypes(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(columnTypes) == 0 {
+		respondError(c, tableNotFound(tableName))
+		return
+	}
+	types := make(map[string]string, len(columnTypes))
+	for _, col := range columnTypes {
+		types[col.ColumnName] = col.DataType
+	}
+
+	pkColumn, err := getPrimaryKeyColumn(getDB(c), tableName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Для сравнения у таблицы должен быть первичный ключ"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл не загружен"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка открытия файла"})
+		return
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения файла"})
+		return
+	}
+	if err := checkCSVData(data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный файл", "details": err.Error()})
+		return
+	}
+
+	dialect, err := parseCSVDialect(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reader := dialect.newReader(data)
+	headers, err := reader.Read()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения CSV"})
+		return
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения CSV", "details": err.Error()})
+		return
+	}
+
+	pkIndex := -1
+	for i, h := range headers {
+		if _, ok := types[h]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Колонка не найдена в таблице", "column": h})
+			return
+		}
+		if h == pkColumn {
+			pkIndex = i
+		}
+	}
+	if pkIndex < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "В CSV нет колонки первичного ключа", "column": pkColumn})
+		return
+	}
+
+	// Текущие строки в том же строковом виде, что и при экспорте в CSV
+	var rows []map[string]interface{}
+	if err := getDB(c).Raw(fmt.Sprintf("SELECT * FROM %s", quoteIdentifier(tableName))).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	current := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		current[exportFormat{}.formatValue(row[pkColumn], types[pkColumn])] = row
+	}
+
+	var added, changed, unchanged int
+	var samples []rowDiff
+	addSample := func(d rowDiff) {
+		if len(samples) < diffSampleSize {
+			samples = append(samples, d)
+		}
+	}
+
+	seen := make(map[string]bool, len(records))
+	for _, record := range records {
+		if len(record) != len(headers) {
+			continue
+		}
+		key := record[pkIndex]
+		seen[key] = true
+
+		row, ok := current[key]
+		if !ok {
+			added++
+			addSample(rowDiff{Type: "added", Key: key})
+			continue
+		}
+
+		changes := map[string][2]string{}
+		for i, h := range headers {
+			value := exportFormat{}.formatValue(row[h], types[h])
+			if !csvValuesEqual(value, record[i], types[h]) {
+				changes[h] = [2]string{value, record[i]}
+			}
+		}
+		if len(changes) == 0 {
+			unchanged++
+			continue
+		}
+		changed++
+		addSample(rowDiff{Type: "changed", Key: key, Changes: changes})
+	}
+
+	removed := 0
+	for key := range current {
+		if !seen[key] {
+			removed++
+			addSample(rowDiff{Type: "removed", Key: key})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"table":     tableName,
+		"key":       pkColumn,
+		"added":     added,
+		"removed":   removed,
+		"changed":   changed,
+		"unchanged": unchanged,
+		"samples":   samples,
+	})
+}
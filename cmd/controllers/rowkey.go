@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// rowKeyColumn — колонка с первичным ключом базовой таблицы в результатах запроса
+const rowKeyColumn = "_rowKey"
+
+var (
+	// fromPattern выделяет таблицу и псевдоним из FROM; запятая после них означает несколько таблиц
+	fromPattern = regexp.MustCompile(`(?i)\bFROM\s+([a-zA-Z_][a-zA-Z0-9_]*)(?:\s+(?:AS\s+)?([a-zA-Z_][a-zA-Z0-9_]*))?\s*(,)?`)
+	// multiRowPattern — конструкции, после которых строка результата не соответствует одной строке таблицы
+	multiRowPattern = regexp.MustCompile(`(?i)\b(JOIN|UNION|INTERSECT|EXCEPT|GROUP\s+BY|HAVING|DISTINCT|COUNT|SUM|AVG|MIN|MAX|ARRAY_AGG|STRING_AGG)\b|\(\s*SELECT\b`)
+	fromKeyword     = regexp.MustCompile(`(?i)\bFROM\b`)
+)
+
+// clauseKeywords — слова, которые после имени таблицы начинают следующую часть запроса, а не псевдоним
+var clauseKeywords = map[string]bool{
+	"WHERE": true, "ORDER": true, "LIMIT": true, "OFFSET": true, "FOR": true, "FETCH": true, "WINDOW": true,
+}
+
+// withRowKey для SELECT из одной таблицы добавляет первичный ключ этой таблицы колонкой _rowKey.
+// Возвращает переписанный запрос, таблицу и колонку ключа; ok=false, если запрос не подходит
+func withRowKey(db *gorm.DB, query string) (rewritten, table, pkColumn string, ok bool) {
+	q := stripLeadingComments(trimStatement(query))
+	if statementKind(q) != "SELECT" || !isSingleStatement(q) {
+		return "", "", "", false
+	}
+	if multiRowPattern.MatchString(q) || len(fromKeyword.FindAllStringIndex(q, -1)) != 1 {
+		return "", "", "", false
+	}
+
+	m := fromPattern.FindStringSubmatch(q)
+	if m == nil || m[3] != "" {
+		return "", "", "", false
+	}
+	table, alias := m[1], m[2]
+	if clauseKeywords[strings.ToUpper(alias)] {
+		alias = ""
+	}
+
+	pkColumn, err := getPrimaryKeyColumn(db, table)
+	if err != nil {
+		return "", "", "", false
+	}
+
+	qualifier := table
+	if alias != "" {
+		qualifier = alias
+	}
+
+	// Ключ добавляется первой колонкой сразу после SELECT
+	rewritten = fmt.Sprintf("SELECT %s.%s AS %s, %s",
+		quoteIdentifier(qualifier), quoteIdentifier(pkColumn), quoteIdentifier(rowKeyColumn),
+		strings.TrimSpace(q[len("SELECT"):]))
+	return rewritten, table, pkColumn, true
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// updateRowRouter создает таблицу test_update_row и маршруты для работы с ее строками
+func updateRowRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	db := testDB(t)
+	dropTestTable(t, db, "test_update_row")
+	if err := db.Exec(`CREATE TABLE test_update_row (
+		id SERIAL PRIMARY KEY,
+		title TEXT,
+		note TEXT,
+		meta JSONB,
+		updated TIMESTAMPTZ
+	)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`INSERT INTO test_update_row (title, meta) VALUES ('a', '{"n": 1}')`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return newTestRouter(func(r *gin.Engine) {
+		r.PUT("/api/tables/:name/rows/:id", UpdateRow)
+		r.GET("/api/tables/:name/rows/:id", GetRow)
+	})
+}
+
+// TestUpdateRowNormalized проверяет, что UpdateRow возвращает строку в том же виде, что GetRow:
+// JSON-колонки объектом, NULL — явным null
+func TestUpdateRowNormalized(t *testing.T) {
+	r := updateRowRouter(t)
+
+	status, resp := doJSON(t, r, http.MethodPut, "/api/tables/test_update_row/rows/1", map[string]interface{}{"title": "b"})
+	if status != http.StatusOK {
+		t.Fatalf("обновление: %d %v", status, resp)
+	}
+	data := resp["data"].(map[string]interface{})
+	if _, ok := data["meta"].(map[string]interface{}); !ok {
+		t.Fatalf("meta: %T %v, want объект", data["meta"], data["meta"])
+	}
+	if v, ok := data["note"]; !ok || v != nil {
+		t.Fatalf("note: %v (есть ключ: %v), want null", v, ok)
+	}
+
+	_, got := doJSON(t, r, http.MethodGet, "/api/tables/test_update_row/rows/1", nil)
+	for column, val := range got["data"].(map[string]interface{}) {
+		if _, ok := data[column]; !ok {
+			t.Errorf("колонка %s есть в GetRow (%v), но нет в ответе UpdateRow", column, val)
+		}
+	}
+	if changed := resp["changed"].([]interface{}); len(changed) != 1 || changed[0] != "title" {
+		t.Fatalf("changed: %v, want [title]", changed)
+	}
+}
+
+func TestUpdateRowUnknownColumns(t *testing.T) {
+	r := updateRowRouter(t)
+
+	status, resp := doJSON(t, r, http.MethodPut, "/api/tables/test_update_row/rows/1", map[string]interface{}{"title": "b", "titel": "c"})
+	if status != http.StatusBadRequest {
+		t.Fatalf("неизвестная колонка: %d %v, want 400", status, resp)
+	}
+	if unknown, _ := resp["unknown"].([]interface{}); len(unknown) != 1 || unknown[0] != "titel" {
+		t.Fatalf("unknown: %v, want [titel]", resp["unknown"])
+	}
+}
+
+// TestUpdateRowNestedJSON проверяет, что вложенные объекты и массивы в JSONB-колонке
+// сохраняются и читаются без изменений
+func TestUpdateRowNestedJSON(t *testing.T) {
+	r := updateRowRouter(t)
+
+	meta := map[string]interface{}{
+		"a":    map[string]interface{}{"b": []interface{}{1.0, 2.0, map[string]interface{}{"c": nil}}},
+		"tags": []interface{}{"x", "y"},
+		"ok":   true,
+	}
+	status, resp := doJSON(t, r, http.MethodPut, "/api/tables/test_update_row/rows/1", map[string]interface{}{"meta": meta})
+	if status != http.StatusOK {
+		t.Fatalf("обновление: %d %v", status, resp)
+	}
+	if got := resp["data"].(map[string]interface{})["meta"]; !reflect.DeepEqual(got, meta) {
+		t.Fatalf("meta в ответе: %v, want %v", got, meta)
+	}
+
+	_, resp = doJSON(t, r, http.MethodGet, "/api/tables/test_update_row/rows/1", nil)
+	if got := resp["data"].(map[string]interface{})["meta"]; !reflect.DeepEqual(got, meta) {
+		t.Fatalf("meta после чтения: %v, want %v", got, meta)
+	}
+}
+
+// TestAddRowNestedJSONRoundTrip проверяет, что вложенный объект и массив, добавленные AddRow
+// в JSONB-колонку, GetTableData возвращает JSON-значениями, а не строкой
+func TestAddRowNestedJSONRoundTrip(t *testing.T) {
+	r := updateRowRouter(t)
+	r.POST("/api/tables/:name/rows", AddRow)
+	r.GET("/api/tables/:name/data", GetTableData)
+
+	meta := map[string]interface{}{
+		"a":    map[string]interface{}{"b": []interface{}{1.0, map[string]interface{}{"c": "d"}}},
+		"tags": []interface{}{"x", "y"},
+	}
+	status, resp := doJSON(t, r, http.MethodPost, "/api/tables/test_update_row/rows",
+		map[string]interface{}{"title": "nested", "meta": meta})
+	if status != http.StatusOK {
+		t.Fatalf("добавление: %d %v", status, resp)
+	}
+
+	status, resp = doJSON(t, r, http.MethodGet, "/api/tables/test_update_row/data?filter=title:eq:nested", nil)
+	if status != http.StatusOK {
+		t.Fatalf("чтение: %d %v", status, resp)
+	}
+	rows, _ := resp["rows"].([]interface{})
+	if len(rows) != 1 {
+		t.Fatalf("строк: %d, want 1: %v", len(rows), resp)
+	}
+	got := rows[0].(map[string]interface{})["meta"]
+	if _, isString := got.(string); isString {
+		t.Fatalf("meta вернулась строкой: %q", got)
+	}
+	if !reflect.DeepEqual(got, meta) {
+		t.Fatalf("meta: %v, want %v", got, meta)
+	}
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"server/initializers"
+)
+
+// schemaLockClass — первый ключ advisory-блокировок схемы, чтобы не пересекаться
+// с другими advisory-блокировками в той же базе; второй ключ — hashtext(имя таблицы)
+const schemaLockClass = 0x5343
+
+// schemaLockPoll — пауза между попытками взять блокировку
+const schemaLockPoll = 50 * time.Millisecond
+
+// lockTableSchema берет advisory-блокировку схемы таблицы до конца транзакции tx,
+// чтобы одновременные изменения структуры одной таблицы выполнялись по очереди.
+// Если блокировку не удалось взять за SCHEMA_LOCK_WAIT_MS, возвращает 409.
+// При SCHEMA_LOCK=false ничего не делает
+func lockTableSchema(tx *gorm.DB, table string) error {
+	cfg := initializers.GetConfig()
+	if !cfg.SchemaLock {
+		return nil
+	}
+
+	deadline := time.Now().Add(cfg.SchemaLockWait)
+	for {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?, hashtext(?))", schemaLockClass, table).Scan(&locked).Error; err != nil {
+			return newAPIError(http.StatusInternalServerError, "Ошибка блокировки схемы таблицы", err)
+		}
+		if locked {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			apiErr := newAPIError(http.StatusConflict, "Структура таблицы изменяется другим запросом", nil)
+			apiErr.Body["table"] = table
+			apiErr.Body["hint"] = "Повторите запрос через несколько секунд"
+			return apiErr
+		}
+		time.Sleep(schemaLockPoll)
+	}
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/initializers"
+)
+
+// statementResult — итог выполнения одного запроса скрипта
+type statementResult struct {
+	Index        int    `json:"index"`
+	Kind         string `json:"kind"`
+	RowsAffected int64  `json:"rowsAffected"`
+}
+
+// ImportSQL выполняет загруженный SQL-скрипт (поле file), например DDL из GetTableDDL
+// или .sql-файлы архива BackupDB с ?schemaOnly=true. Запросы выполняются по очереди в одной транзакции; при ошибке
+// все изменения откатываются. В режиме READ_ONLY_MODE разрешены только SELECT
+func ImportSQL(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл не загружен"})
+		return
+	}
+
+	maxSize := initializers.GetConfig().SQLImportMaxSize
+	if file.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   "Файл слишком большой",
+			"size":    file.Size,
+			"maxSize": maxSize,
+		})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка открытия файла"})
+		return
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения файла"})
+		return
+	}
+	if !utf8.Valid(data) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл не в кодировке UTF-8"})
+		return
+	}
+
+	statements := splitStatements(string(data))
+	if len(statements) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "В файле нет SQL-запросов"})
+		return
+	}
+
+	if initializers.GetConfig().ReadOnlyMode {
+		for i, stmt := range statements {
+			if !isSelectQuery(stmt) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "В режиме только для чтения разрешены только SELECT",
+					"index": i,
+					"kind":  statementKind(stmt),
+				})
+				return
+			}
+		}
+	}
+
+	results := make([]statementResult, 0, len(statements))
+	ok := WithTransaction(c, func(tx *gorm.DB) error {
+		if initializers.GetConfig().ReadOnlyMode {
+			if err := setReadOnly(tx); err != nil {
+				return err
+			}
+		}
+		for i, stmt := range statements {
+			result := tx.Exec(stmt)
+			if isReadOnlyViolation(result.Error) {
+				apiErr := readOnlyError(result.Error)
+				apiErr.Body["index"] = i
+				apiErr.Body["kind"] = statementKind(stmt)
+				return apiErr
+			}
+			if result.Error != nil {
+				apiErr := newAPIError(http.StatusBadRequest, "Ошибка выполнения SQL, изменения отменены", result.Error)
+				apiErr.Body["index"] = i
+				apiErr.Body["sql"] = stmt
+				apiErr.Body["executed"] = results
+				return apiErr
+			}
+			results = append(results, statementResult{
+				Index:        i,
+				Kind:         statementKind(stmt),
+				RowsAffected: result.RowsAffected,
+			})
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "Скрипт выполнен",
+		"statements": results,
+	})
+}
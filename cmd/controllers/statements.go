@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stripLeadingComments убирает пробелы и SQL-комментарии в начале запроса
+func stripLeadingComments(query string) string {
+	q := strings.TrimSpace(query)
+	for {
+		switch {
+		case strings.HasPrefix(q, "--"):
+			idx := strings.Index(q, "\n")
+			if idx < 0 {
+				return ""
+			}
+			q = strings.TrimSpace(q[idx+1:])
+		case strings.HasPrefix(q, "/*"):
+			idx := strings.Index(q, "*/")
+			if idx < 0 {
+				return ""
+			}
+			q = strings.TrimSpace(q[idx+2:])
+		default:
+			return q
+		}
+	}
+}
+
+// statementKind возвращает первое ключевое слово запроса в верхнем регистре (SELECT, INSERT, ...)
+func statementKind(query string) string {
+	q := stripLeadingComments(query)
+	q = strings.TrimLeft(q, "(")
+	end := strings.IndexFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if end < 0 {
+		end = len(q)
+	}
+	return strings.ToUpper(q[:end])
+}
+
+// trimStatement убирает пробелы и завершающие точки с запятой
+func trimStatement(query string) string {
+	return strings.TrimRight(strings.TrimSpace(query), "; \t\n")
+}
+
+// isSingleStatement проверяет, что в запросе не больше одного оператора: разделители ';'
+// в литералах, идентификаторах в кавычках, комментариях и долларовых кавычках не учитываются
+func isSingleStatement(query string) bool {
+	return len(splitStatements(query)) <= 1
+}
+
+// isSelectQuery проверяет, что запрос — одиночный SELECT (в том числе с WITH/VALUES/TABLE).
+// Проверяется только первое ключевое слово: WITH с DELETE ... RETURNING или SELECT INTO
+// тоже проходят, поэтому в режиме только для чтения запросы выполняются в транзакции
+// только для чтения (см. setReadOnly), а эта проверка лишь отсекает очевидную запись
+func isSelectQuery(query string) bool {
+	switch statementKind(query) {
+	case "SELECT", "WITH", "VALUES", "TABLE":
+		return isSingleStatement(query)
+	}
+	return false
+}
+
+// hasTopLevelLimit проверяет, что у запроса есть LIMIT или FETCH на верхнем уровне:
+// ограничения внутри подзапросов и строковых литералов не учитываются
+func hasTopLevelLimit(query string) bool {
+	depth := 0
+	inQuote := false
+	var word strings.Builder
+
+	check := func() bool {
+		w := strings.ToUpper(word.String())
+		word.Reset()
+		return w == "LIMIT" || w == "FETCH"
+	}
+
+	for _, r := range stripLeadingComments(query) {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth == 0 && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'):
+			word.WriteRune(r)
+			continue
+		}
+		if depth == 0 && word.Len() > 0 && check() {
+			return true
+		}
+		word.Reset()
+	}
+	return depth == 0 && check()
+}
+
+// splitStatements делит SQL-скрипт на запросы по ';' вне строковых литералов ('...', E'...'),
+// идентификаторов в кавычках, комментариев и тел в долларовых кавычках ($$...$$, $tag$...$tag$).
+// Пустые запросы и запросы из одних комментариев отбрасываются
+func splitStatements(script string) []string {
+	var statements []string
+	start := 0
+	add := func(end int) {
+		stmt := strings.TrimSpace(script[start:end])
+		if stripLeadingComments(stmt) != "" {
+			statements = append(statements, stmt)
+		}
+	}
+
+	for i := 0; i < len(script); i++ {
+		if end := skipNonCode(script, i); end != i {
+			i = end
+			continue
+		}
+		if script[i] == ';' {
+			add(i)
+			start = i + 1
+		}
+	}
+	if start < len(script) {
+		add(len(script))
+	}
+	return statements
+}
+
+// skipNonCode возвращает индекс последнего байта строкового литерала ('...', E'...'),
+// идентификатора в кавычках, комментария или тела в долларовых кавычках, которые начинаются
+// в script[i], или i, если там обычный код. Незакрытые доходят до конца script
+func skipNonCode(script string, i int) int {
+	switch ch := script[i]; {
+	case ch == '-' && strings.HasPrefix(script[i:], "--"):
+		end := strings.IndexByte(script[i:], '\n')
+		if end < 0 {
+			return len(script) - 1
+		}
+		return i + end
+	case ch == '/' && strings.HasPrefix(script[i:], "/*"):
+		end := strings.Index(script[i+2:], "*/")
+		if end < 0 {
+			return len(script) - 1
+		}
+		return i + end + 3
+	case ch == '\'' || ch == '"':
+		// В E'...' обратная косая черта экранирует следующий символ
+		escapes := ch == '\'' && i > 0 && (script[i-1] == 'E' || script[i-1] == 'e')
+		for i++; i < len(script); i++ {
+			if escapes && script[i] == '\\' {
+				i++
+				continue
+			}
+			if script[i] == ch {
+				// Удвоенная кавычка — часть литерала
+				if i+1 < len(script) && script[i+1] == ch {
+					i++
+					continue
+				}
+				return i
+			}
+		}
+		return len(script) - 1
+	case ch == '$':
+		tag := dollarQuoteTag(script[i:])
+		if tag == "" {
+			return i
+		}
+		end := strings.Index(script[i+len(tag):], tag)
+		if end < 0 {
+			return len(script) - 1
+		}
+		return i + len(tag) + end + len(tag) - 1
+	}
+	return i
+}
+
+// numberPlaceholders заменяет плейсхолдеры ? вне литералов и комментариев на $1, $2, ...,
+// как их подставляет gorm при выполнении с аргументами. Возвращает новый запрос, число
+// плейсхолдеров и для каждого символа (руны) нового запроса — номер символа в исходном
+func numberPlaceholders(query string) (string, int, []int) {
+	var b strings.Builder
+	var origin []int
+	count := 0
+	runeIndex := 0
+	copyRange := func(from, to int) {
+		for _, r := range query[from:to] {
+			b.WriteRune(r)
+			origin = append(origin, runeIndex)
+			runeIndex++
+		}
+	}
+
+	for i := 0; i < len(query); i++ {
+		if end := skipNonCode(query, i); end != i {
+			copyRange(i, end+1)
+			i = end
+			continue
+		}
+		if query[i] != '?' {
+			_, size := utf8.DecodeRuneInString(query[i:])
+			copyRange(i, i+size)
+			i += size - 1
+			continue
+		}
+		count++
+		placeholder := fmt.Sprintf("$%d", count)
+		b.WriteString(placeholder)
+		for range placeholder {
+			origin = append(origin, runeIndex)
+		}
+		runeIndex++
+	}
+	return b.String(), count, origin
+}
+
+// dollarQuoteTag возвращает открывающий тег долларовых кавычек ($$ или $tag$) в начале s
+// или пустую строку, если s с него не начинается (например, параметр $1)
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		ch := rune(s[i])
+		switch {
+		case ch == '$':
+			return s[:i+1]
+		case unicode.IsLetter(ch) || ch == '_' || (i > 1 && unicode.IsDigit(ch)):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
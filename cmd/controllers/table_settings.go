@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// tableSort — сортировка строк таблицы по одной колонке
+type tableSort struct {
+	Column string
+	Desc   bool
+}
+
+// parseTableSort разбирает "column" или "column:asc|desc" и проверяет колонку по types
+func parseTableSort(raw string, types map[string]string) (tableSort, error) {
+	column, order, _ := strings.Cut(strings.TrimSpace(raw), ":")
+	if _, ok := types[column]; !ok {
+		return tableSort{}, fmt.Errorf("колонка '%s' не найдена", column)
+	}
+
+	switch strings.ToLower(order) {
+	case "", "asc":
+		return tableSort{Column: column}, nil
+	case "desc":
+		return tableSort{Column: column, Desc: true}, nil
+	}
+	return tableSort{}, fmt.Errorf("порядок сортировки '%s' должен быть asc или desc", order)
+}
+
+// orderClause возвращает выражение для ORDER BY
+func (s tableSort) orderClause() string {
+	if s.Desc {
+		return quoteIdentifier(s.Column) + " DESC"
+	}
+	return quoteIdentifier(s.Column)
+}
+
+func (s tableSort) String() string {
+	if s.Desc {
+		return s.Column + ":desc"
+	}
+	return s.Column
+}
+
+// resolveTableSort возвращает сортировку из ?sort=column[:desc], а без параметра —
+// сохраненную в метаданных таблицы. Сохраненная сортировка по удаленной колонке игнорируется.
+// ok=false — сортировка не задана
+func resolveTableSort(c *gin.Context, db *gorm.DB, tableName string, types map[string]string) (tableSort, bool, error) {
+	if raw := c.Query("sort"); raw != "" {
+		s, err := parseTableSort(raw, types)
+		return s, err == nil, err
+	}
+
+	var meta model.TableMeta
+	if err := db.Where("name = ?", tableName).First(&meta).Error; err != nil || meta.DefaultSort == "" {
+		return tableSort{}, false, nil
+	}
+	s, err := parseTableSort(meta.DefaultSort, types)
+	if err != nil {
+		return tableSort{}, false, nil
+	}
+	return s, true, nil
+}
+
+// UpdateTableSettings меняет настройки таблицы в метаданных.
+// defaultSort — "column" или "column:desc", пустая строка сбрасывает сортировку
+func UpdateTableSettings(c *gin.Context) {
+	tableName := c.Param("name")
+
+	var req struct {
+		DefaultSort *string `json:"defaultSort"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.DefaultSort == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указаны настройки", "settings": []string{"defaultSort"}})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if *req.DefaultSort != "" {
+		columnTypes, err := getColumnTypes(getDB(c), tableName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		types := make(map[string]string, len(columnTypes))
+		for _, col := range columnTypes {
+			types[col.ColumnName] = col.DataType
+		}
+
+		s, err := parseTableSort(*req.DefaultSort, types)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "defaultSort"})
+			return
+		}
+		updates["default_sort"] = s.String()
+	} else {
+		updates["default_sort"] = ""
+	}
+
+	result := getDB(c).Model(&model.TableMeta{}).Where("name = ?", tableName).Updates(updates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Метаданные таблицы не найдены"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"table":       tableName,
+		"defaultSort": updates["default_sort"],
+	})
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// timestampColumns — колонки аудита, добавляемые при создании таблицы с timestamps: true
+var timestampColumns = []string{
+	"created_at TIMESTAMPTZ NOT NULL DEFAULT now()",
+	"updated_at TIMESTAMPTZ NOT NULL DEFAULT now()",
+}
+
+// updatedAtFunction — триггерная функция, общая для всех таблиц с колонками аудита
+const updatedAtFunction = `
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS trigger AS $$
+BEGIN
+	NEW.updated_at = now();
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql`
+
+// createUpdatedAtTrigger вешает на таблицу триггер, обновляющий updated_at при каждом UPDATE
+func createUpdatedAtTrigger(tx *gorm.DB, tableName string) error {
+	if err := tx.Exec(updatedAtFunction).Error; err != nil {
+		return err
+	}
+	return tx.Exec(fmt.Sprintf(
+		"CREATE TRIGGER set_updated_at BEFORE UPDATE ON %s FOR EACH ROW EXECUTE FUNCTION set_updated_at()",
+		quoteIdentifier(tableName),
+	)).Error
+}
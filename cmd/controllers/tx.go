@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/initializers"
+)
+
+// apiError — ошибка с HTTP-статусом и телом ответа в стандартном формате {"error": ...}
+type apiError struct {
+	Status int
+	Body   gin.H
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d: %v", e.Status, e.Body["error"])
+}
+
+// newAPIError создает ошибку с сообщением и, если передана, причиной в "details"
+func newAPIError(status int, message string, cause error) *apiError {
+	body := gin.H{"error": message}
+	if cause != nil {
+		body["details"] = cause.Error()
+	}
+	return &apiError{Status: status, Body: body}
+}
+
+// getDB возвращает подключение текущего запроса (см. middleware.RequestDB): оно привязано
+// к контексту запроса, поэтому запросы к БД отменяются, когда клиент отключается.
+// Без middleware возвращается глобальное подключение с контекстом запроса
+func getDB(c *gin.Context) *gorm.DB {
+	if db, ok := c.Get(initializers.RequestDBKey); ok {
+		return db.(*gorm.DB)
+	}
+	return initializers.GetDB().WithContext(c.Request.Context())
+}
+
+// beginTx начинает транзакцию на подключении запроса с его statement_timeout
+func beginTx(c *gin.Context) *gorm.DB {
+	return initializers.BeginTx(getDB(c))
+}
+
+// setReadOnly переводит транзакцию в режим только для чтения: Postgres отклонит любую запись,
+// в том числе через data-modifying CTE и SELECT INTO. Вызывается до первого запроса в транзакции
+func setReadOnly(tx *gorm.DB) error {
+	return tx.Exec("SET TRANSACTION READ ONLY").Error
+}
+
+// beginReadOnlyTx начинает транзакцию только для чтения на подключении запроса
+func beginReadOnlyTx(c *gin.Context) (*gorm.DB, error) {
+	tx := beginTx(c)
+	if err := setReadOnly(tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// respondError отправляет ошибку клиенту: apiError — как есть, остальные — 500
+func respondError(c *gin.Context, err error) {
+	if apiErr, ok := err.(*apiError); ok {
+		c.JSON(apiErr.Status, apiErr.Body)
+		return
+	}
+	if isQueryCanceled(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "Превышено время выполнения запроса к БД",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Внутренняя ошибка сервера",
+		"details": err.Error(),
+	})
+}
+
+// WithTransaction выполняет fn в транзакции: фиксирует ее при nil, откатывает при ошибке
+// или панике и отправляет ошибку клиенту. Возвращает true, если транзакция зафиксирована
+func WithTransaction(c *gin.Context, fn func(tx *gorm.DB) error) bool {
+	return runTransaction(c, beginTx(c), fn)
+}
+
+// runTransaction — WithTransaction для уже начатой транзакции tx (например, copyTx)
+func runTransaction(c *gin.Context, tx *gorm.DB, fn func(tx *gorm.DB) error) (ok bool) {
+	if tx.Error != nil {
+		respondError(c, newAPIError(http.StatusInternalServerError, "Ошибка начала транзакции", tx.Error))
+		return false
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			respondError(c, newAPIError(http.StatusInternalServerError, "Ошибка выполнения транзакции", fmt.Errorf("%v", r)))
+			ok = false
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		respondError(c, err)
+		return false
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(c, newAPIError(http.StatusInternalServerError, "Ошибка фиксации транзакции", err))
+		return false
+	}
+
+	return true
+}
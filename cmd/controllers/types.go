@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// columnInfo описывает колонку таблицы из information_schema
+type columnInfo struct {
+	ColumnName string `gorm:"column:column_name" json:"column_name"`
+	DataType   string `gorm:"column:data_type" json:"data_type"`
+}
+
+// getColumnTypes возвращает колонки таблицы с их типами в порядке ordinal_position
+func getColumnTypes(db *gorm.DB, tableName string) ([]columnInfo, error) {
+	var columns []columnInfo
+	if err := db.Raw(`
+		SELECT column_name, data_type
+		FROM information_schema.columns
+		WHERE table_name = ?
+		ORDER BY ordinal_position
+	`, tableName).Scan(&columns).Error; err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+// tableExists проверяет существование таблицы по точному имени
+func tableExists(db *gorm.DB, tableName string) (bool, error) {
+	var exists bool
+	err := db.Raw(`
+		SELECT EXISTS (
+			SELECT FROM information_schema.tables 
+			WHERE table_name = ?
+		)`, tableName).Scan(&exists).Error
+	return exists, err
+}
+
+// columnExists проверяет существование колонки таблицы по точному имени
+func columnExists(db *gorm.DB, tableName, columnName string) (bool, error) {
+	var exists bool
+	err := db.Raw(`
+		SELECT EXISTS (
+			SELECT FROM information_schema.columns
+			WHERE table_name = ? AND column_name = ?
+		)`, tableName, columnName).Scan(&exists).Error
+	return exists, err
+}
+
+// unknownColumnsError возвращает 400 со списком ключей row, которых нет среди columns,
+// или nil, если все ключи — колонки таблицы
+func unknownColumnsError(row map[string]interface{}, columns []string) *apiError {
+	valid := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		valid[col] = true
+	}
+
+	var unknown []string
+	for field := range row {
+		if !valid[field] {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	apiErr := newAPIError(http.StatusBadRequest, "Неизвестные колонки", nil)
+	apiErr.Body["unknown"] = unknown
+	apiErr.Body["columns"] = columns
+	return apiErr
+}
+
+// tableNotFound — ошибка 404 для отсутствующей таблицы в едином формате
+func tableNotFound(tableName string) *apiError {
+	apiErr := newAPIError(http.StatusNotFound, fmt.Sprintf("Таблица '%s' не найдена", tableName), nil)
+	apiErr.Body["table"] = tableName
+	return apiErr
+}
+
+// requireTable проверяет существование таблицы и, если ее нет или проверка не удалась,
+// сам отвечает 404 или 500. Возвращает false, когда обработчик должен завершиться
+func requireTable(c *gin.Context, tableName string) bool {
+	exists, err := tableExists(getDB(c), tableName)
+	if err != nil {
+		respondError(c, newAPIError(http.StatusInternalServerError, "Ошибка проверки таблицы", err))
+		return false
+	}
+	if !exists {
+		respondError(c, tableNotFound(tableName))
+		return false
+	}
+	return true
+}
+
+func isIntegerType(dataType string) bool {
+	switch dataType {
+	case "smallint", "integer", "bigint":
+		return true
+	}
+	return false
+}
+
+func isFloatType(dataType string) bool {
+	switch dataType {
+	case "numeric", "real", "double precision":
+		return true
+	}
+	return false
+}
+
+func isTimeType(dataType string) bool {
+	return dataType == "date" || strings.HasPrefix(dataType, "timestamp") || strings.HasPrefix(dataType, "time")
+}
+
+func isTextType(dataType string) bool {
+	switch dataType {
+	case "text", "character varying", "character":
+		return true
+	}
+	return false
+}
+
+func isJSONType(dataType string) bool {
+	return dataType == "json" || dataType == "jsonb"
+}
+
+// coerceValue приводит значение из БД к JSON-типу по data_type колонки.
+// Если привести не удалось, значение возвращается как есть.
+// []byte текстовых колонок становится строкой; bytea остается []byte и уходит в JSON как base64
+func coerceValue(val interface{}, dataType string) interface{} {
+	if val == nil {
+		return nil
+	}
+
+	if b, ok := val.([]byte); ok {
+		if dataType == "bytea" {
+			return b
+		}
+		val = string(b)
+	}
+
+	switch {
+	case isIntegerType(dataType):
+		if s, ok := val.(string); ok {
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n
+			}
+		}
+	case isFloatType(dataType):
+		switch v := val.(type) {
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		case float32:
+			return float64(v)
+		}
+	case dataType == "boolean":
+		if s, ok := val.(string); ok {
+			switch strings.ToLower(s) {
+			case "t", "true":
+				return true
+			case "f", "false":
+				return false
+			}
+		}
+	case isTimeType(dataType):
+		if t, ok := val.(time.Time); ok {
+			return t.Format(time.RFC3339)
+		}
+	case isJSONType(dataType):
+		if s, ok := val.(string); ok {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(s), &parsed); err == nil {
+				return parsed
+			}
+		}
+	}
+
+	return val
+}
+
+// normalizeRow приводит значения строки к JSON-типам и добавляет отсутствующие колонки
+// со значением nil, чтобы NULL всегда приходил явным null, а не пропущенным ключом
+func normalizeRow(row map[string]interface{}, columns []string, types map[string]string) {
+	for name, val := range row {
+		row[name] = coerceValue(val, types[name])
+	}
+	for _, name := range columns {
+		if _, ok := row[name]; !ok {
+			row[name] = nil
+		}
+	}
+}
+
+// getColumnOrder возвращает сохраненный в метаданных порядок колонок (или nil)
+func getColumnOrder(db *gorm.DB, tableName string) []string {
+	var meta model.TableMeta
+	if err := db.Where("name = ?", tableName).First(&meta).Error; err != nil {
+		return nil
+	}
+
+	var order []string
+	if meta.ColumnOrder != "" {
+		json.Unmarshal([]byte(meta.ColumnOrder), &order)
+	}
+	return order
+}
+
+// applyColumnOrder упорядочивает колонки по сохраненному порядку.
+// Колонки, которых нет в порядке (например, добавленные позже), идут в конце
+func applyColumnOrder(columns []string, order []string) []string {
+	if len(order) == 0 {
+		return columns
+	}
+
+	existing := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		existing[col] = true
+	}
+
+	result := make([]string, 0, len(columns))
+	placed := make(map[string]bool, len(columns))
+	for _, col := range order {
+		if existing[col] && !placed[col] {
+			result = append(result, col)
+			placed[col] = true
+		}
+	}
+	for _, col := range columns {
+		if !placed[col] {
+			result = append(result, col)
+		}
+	}
+	return result
+}
+
+// coerceInput приводит значение из JSON запроса к типу колонки перед записью в БД
+func coerceInput(val interface{}, dataType string) (interface{}, error) {
+	if val == nil {
+		return nil, nil
+	}
+
+	switch {
+	case isIntegerType(dataType):
+		switch v := val.(type) {
+		case float64:
+			if v != float64(int64(v)) {
+				return nil, fmt.Errorf("ожидается целое число, получено %v", v)
+			}
+			return int64(v), nil
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("ожидается целое число, получено '%s'", v)
+			}
+			return n, nil
+		}
+	case isFloatType(dataType):
+		switch v := val.(type) {
+		case float64:
+			return v, nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("ожидается число, получено '%s'", v)
+			}
+			return f, nil
+		}
+	case dataType == "boolean":
+		switch v := val.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("ожидается true/false, получено '%s'", v)
+			}
+			return b, nil
+		}
+	case isTimeType(dataType):
+		if v, ok := val.(string); ok {
+			if dataType == "time without time zone" || dataType == "time with time zone" {
+				return v, nil
+			}
+			for _, layout := range timestampLayouts {
+				if t, err := time.Parse(layout, v); err == nil {
+					return t, nil
+				}
+			}
+			return nil, fmt.Errorf("ожидается дата, получено '%s'", v)
+		}
+	case dataType == "uuid":
+		if v, ok := val.(string); ok && uuidPattern.MatchString(v) {
+			return v, nil
+		}
+		return nil, fmt.Errorf("ожидается UUID, получено '%v'", val)
+	case isJSONType(dataType):
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	default:
+		switch v := val.(type) {
+		case string:
+			return v, nil
+		case float64, bool:
+			return fmt.Sprintf("%v", v), nil
+		}
+	}
+
+	return nil, fmt.Errorf("недопустимое значение %v для типа %s", val, dataType)
+}
+
+// getSerialColumns возвращает автоинкрементные колонки таблицы (SERIAL и IDENTITY)
+func getSerialColumns(db *gorm.DB, tableName string) (map[string]bool, error) {
+	var names []string
+	if err := db.Raw(`
+		SELECT column_name
+		FROM information_schema.columns
+		WHERE table_name = ?
+		AND (column_default LIKE 'nextval(%' OR is_identity = 'YES')
+	`, tableName).Scan(&names).Error; err != nil {
+		return nil, err
+	}
+
+	serial := make(map[string]bool, len(names))
+	for _, name := range names {
+		serial[name] = true
+	}
+	return serial, nil
+}
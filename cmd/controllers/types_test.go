@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUnknownColumnsError(t *testing.T) {
+	columns := []string{"id", "title", "meta"}
+	tests := []struct {
+		row     map[string]interface{}
+		unknown []string
+	}{
+		{map[string]interface{}{}, nil},
+		{map[string]interface{}{"title": "a", "meta": nil}, nil},
+		{map[string]interface{}{"title": "a", "titel": "b"}, []string{"titel"}},
+		{map[string]interface{}{"z": 1, "a": 2, "id": 3}, []string{"a", "z"}},
+		{map[string]interface{}{"Title": "a"}, []string{"Title"}},
+	}
+	for _, tt := range tests {
+		apiErr := unknownColumnsError(tt.row, columns)
+		if tt.unknown == nil {
+			if apiErr != nil {
+				t.Errorf("%v: %v, want nil", tt.row, apiErr)
+			}
+			continue
+		}
+		if apiErr == nil || apiErr.Status != 400 || !reflect.DeepEqual(apiErr.Body["unknown"], tt.unknown) {
+			t.Errorf("%v: %v, want 400 с unknown %v", tt.row, apiErr, tt.unknown)
+		}
+	}
+}
+
+// TestNormalizeRowNulls проверяет, что NULL и отсутствующая колонка приходят явным null,
+// а пустая строка остается пустой строкой
+func TestNormalizeRowNulls(t *testing.T) {
+	columns := []string{"id", "null_note", "empty_note", "missing_note", "meta"}
+	types := map[string]string{
+		"id":           "integer",
+		"null_note":    "text",
+		"empty_note":   "text",
+		"missing_note": "text",
+		"meta":         "jsonb",
+	}
+
+	tests := []struct {
+		column string
+		want   interface{}
+	}{
+		{"null_note", nil},
+		{"empty_note", ""},
+		{"missing_note", nil},
+		{"meta", nil},
+	}
+
+	row := map[string]interface{}{
+		"id":         int64(1),
+		"null_note":  nil,
+		"empty_note": "",
+		"meta":       nil,
+	}
+	normalizeRow(row, columns, types)
+
+	if len(row) != len(columns) {
+		t.Fatalf("в строке %d ключей, want %d: %v", len(row), len(columns), row)
+	}
+	for _, tt := range tests {
+		val, ok := row[tt.column]
+		if !ok {
+			t.Errorf("%s: ключ отсутствует", tt.column)
+			continue
+		}
+		if !reflect.DeepEqual(val, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.column, val, tt.want)
+		}
+	}
+}
+
+func TestTableNotFound(t *testing.T) {
+	for _, name := range []string{"orders", "order", "Имя"} {
+		apiErr := tableNotFound(name)
+		if apiErr.Status != http.StatusNotFound || apiErr.Body["table"] != name || apiErr.Body["error"] == "" {
+			t.Errorf("tableNotFound(%q) = %d %v, want 404 с table", name, apiErr.Status, apiErr.Body)
+		}
+	}
+}
+
+func TestRequireTable(t *testing.T) {
+	db := testDB(t)
+	dropTestTable(t, db, "test_require_table")
+	if err := db.Exec(`CREATE TABLE test_require_table (id SERIAL PRIMARY KEY)`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		table  string
+		exists bool
+	}{
+		{"test_require_table", true},
+		{"test_missing_table", false},
+		{"Test_Require_Table", false}, // имя сравнивается точно, регистр приводит middleware
+	}
+	for _, tt := range tests {
+		exists, err := tableExists(db, tt.table)
+		if err != nil || exists != tt.exists {
+			t.Errorf("tableExists(%q) = %v, %v, want %v", tt.table, exists, err, tt.exists)
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		if got := requireTable(c, tt.table); got != tt.exists {
+			t.Errorf("requireTable(%q) = %v, want %v", tt.table, got, tt.exists)
+		}
+		if !tt.exists && w.Code != http.StatusNotFound {
+			t.Errorf("requireTable(%q): ответ %d, want 404", tt.table, w.Code)
+		}
+	}
+}
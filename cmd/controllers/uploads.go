@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// zipSignatures — сигнатуры начала zip-файла: обычный архив и пустой архив
+var zipSignatures = [][]byte{
+	[]byte("PK\x03\x04"),
+	[]byte("PK\x05\x06"),
+}
+
+// openZipUpload открывает загруженный архив, отличая файл другого формата
+// от поврежденного (например, обрезанного) zip
+func openZipUpload(path string) (*zip.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, newAPIError(http.StatusInternalServerError, "Ошибка открытия файла", err)
+	}
+	header := make([]byte, 4)
+	n, _ := io.ReadFull(f, header)
+	f.Close()
+
+	isZip := false
+	for _, signature := range zipSignatures {
+		if bytes.Equal(header[:n], signature) {
+			isZip = true
+			break
+		}
+	}
+	if !isZip {
+		return nil, newAPIError(http.StatusBadRequest, "Файл не является zip-архивом", nil)
+	}
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, newAPIError(http.StatusBadRequest, "Архив поврежден", err)
+	}
+	return reader, nil
+}
+
+// checkCSVData проверяет, что загруженные данные похожи на текст и декодируются как UTF-8
+func checkCSVData(data []byte) error {
+	if contentType := http.DetectContentType(data); !strings.HasPrefix(contentType, "text/") {
+		return fmt.Errorf("ожидается CSV-файл, получен %s", contentType)
+	}
+	if !utf8.Valid(data) {
+		return fmt.Errorf("файл не в кодировке UTF-8")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+
+	"server/initializers"
+)
+
+// phonePattern — номер в духе E.164: необязательный "+", от 7 до 15 цифр, первая не 0
+var phonePattern = regexp.MustCompile(`^\+?[1-9][0-9]{6,14}$`)
+
+// phoneSeparators — символы, допустимые в записи номера для читаемости
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// fieldValidators — проверки значений по имени из FIELD_VALIDATORS
+var fieldValidators = map[string]func(string) error{
+	"phone": validatePhone,
+	"email": validateEmail,
+}
+
+func validatePhone(value string) error {
+	if !phonePattern.MatchString(phoneSeparators.Replace(value)) {
+		return fmt.Errorf("некорректный номер телефона '%s', пример: +79991234567", value)
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value || !strings.Contains(addr.Address[strings.LastIndex(addr.Address, "@"):], ".") {
+		return fmt.Errorf("некорректный email '%s'", value)
+	}
+	return nil
+}
+
+// fieldError — ошибка проверки значения колонки
+type fieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// validateFields проверяет значения строки по FIELD_VALIDATORS для таблицы.
+// NULL и отсутствующие колонки не проверяются
+func validateFields(tableName string, row map[string]interface{}) []fieldError {
+	var errs []fieldError
+	for column, kind := range initializers.FieldValidators()[tableName] {
+		val, ok := row[column]
+		if !ok || val == nil {
+			continue
+		}
+
+		validate, known := fieldValidators[kind]
+		if !known {
+			continue
+		}
+
+		s, isString := val.(string)
+		if !isString {
+			errs = append(errs, fieldError{Field: column, Error: "ожидается строка"})
+			continue
+		}
+		if err := validate(s); err != nil {
+			errs = append(errs, fieldError{Field: column, Error: err.Error()})
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
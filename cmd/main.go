@@ -3,15 +3,23 @@ package main
 import (
 	"github.com/gin-gonic/gin"
 	"server/cmd/controllers"
+	"server/cmd/middleware"
 	"server/initializers"
 )
 
 func init() {
 	initializers.LoadEnv()
+	initializers.LoadConfig()
 	initializers.ConnectEnv()
+	initializers.Migrate()
 }
 
 func main() {
+	cfg := initializers.GetConfig()
+
+	// Фоновая проверка подключения к БД с переподключением; состояние — в /readyz
+	initializers.WatchDB(cfg.DBHealthInterval, cfg.DBReconnectRetries, cfg.DBReconnectBackoff)
+
 	r := gin.Default()
 
 	// CORS middleware
@@ -26,41 +34,127 @@ func main() {
 		c.Next()
 	})
 
+	// Подключение к БД, привязанное к контексту запроса. Запросы в транзакциях ограничены
+	// STATEMENT_TIMEOUT_MS; заголовок X-Statement-Timeout меняет предел до STATEMENT_TIMEOUT_MAX_MS
+	r.Use(middleware.RequestDB(cfg.StatementTimeout, cfg.StatementTimeoutMax))
+
+	// Сжатие JSON-ответов; порог в байтах задается GZIP_MIN_SIZE
+	r.Use(middleware.Gzip(cfg.GzipMinSize))
+
+	// Ограничение размера JSON-тела запроса; порог в байтах задается MAX_JSON_BODY_SIZE
+	r.Use(middleware.BodyLimit(cfg.MaxJSONBodySize))
+
+	// Имена таблиц и колонок в пути приводятся к политике IDENTIFIER_CASE
+	r.Use(middleware.NormalizeIdentifiers())
+
+	// Ограничение доступных таблиц списком TABLE_ALLOWLIST (если задан). Маршруты
+	// с произвольным SQL при заданном списке закрыты (middleware.RawSQLAllowlist)
+	r.Use(middleware.TableAllowlist())
+
+	// Повтор изменяющих запросов с тем же Idempotency-Key; срок хранения — IDEMPOTENCY_TTL_HOURS.
+	// С IDEMPOTENCY_GZIP=true ответы от IDEMPOTENCY_GZIP_MIN_SIZE байт хранятся сжатыми
+	idempotencyCompressMin := 0
+	if cfg.IdempotencyGzip {
+		idempotencyCompressMin = cfg.IdempotencyGzipMinSize
+	}
+	r.Use(middleware.Idempotency(cfg.IdempotencyTTL, idempotencyCompressMin))
+
+	r.GET("/readyz", controllers.Readyz)
+
 	// 1. Управление таблицами
 	// Управление таблицами
 	r.POST("/api/tables", controllers.CreateTable)                        // Добавление колонки
 	r.DELETE("/api/tables/:name/columns/:column", controllers.DropColumn) // Удаление колонки
 	r.GET("/api/tables", controllers.ListTables)
-	r.DELETE("/api/tables/:name", controllers.DropTable)               // Удаление таблицы
-	r.PUT("/api/tables/:name/columns/:column", controllers.AlterTable) // Переименуем AlterTable в AlterColumn
+	r.GET("/api/meta/column-types", controllers.ListColumnTypes)
+	r.GET("/api/tables/orphans", controllers.GetOrphans)
+	r.DELETE("/api/tables/orphans", controllers.DeleteOrphans)
+	r.DELETE("/api/tables/:name", controllers.DropTable) // Удаление таблицы
+	r.PUT("/api/tables/:name/columns/:column", controllers.AlterColumn)
+	r.PATCH("/api/tables/:name/columns/:column", controllers.UpdateColumnOptions)
+	r.PUT("/api/tables/:name/columns/order", controllers.SetColumnOrder)
+	r.POST("/api/tables/:name/columns/rename-batch", controllers.RenameColumnsBatch)
+	r.PUT("/api/tables/:name/sensitive", controllers.SetTableSensitive)
+	r.PUT("/api/tables/:name/audit", controllers.SetTableAudit)
+	r.PUT("/api/tables/:name/settings", controllers.UpdateTableSettings)
+	r.GET("/api/tables/:name/changes", controllers.GetTableChanges)
+	r.POST("/api/tables/:name/meta/rebuild", controllers.RebuildTableMeta)
+	r.GET("/api/access-log", controllers.GetAccessLog)
+	r.GET("/api/audit", controllers.GetAuditLog)
 
 	// 2. Резервные копии
 	r.GET("/api/backup", controllers.BackupDB)
+	r.POST("/api/backup/tables", controllers.BackupTables)
 	r.POST("/api/restore", controllers.RestoreDB)
 
 	r.POST("/api/tables/:name/restore", controllers.RestoreTable)
+	r.POST("/api/tables/:name/restore/diff", controllers.RestoreTableDiff)
+	r.POST("/api/tables/:name/import", controllers.ImportTable)
+	r.POST("/api/tables/:name/import/preview", controllers.PreviewImport)
+	r.POST("/api/tables/:name/copy-from", controllers.CopyFromTable)
+	r.POST("/api/import/sql", middleware.RawSQLAllowlist(), controllers.ImportSQL)
 	r.GET("/api/tables/:name/backup", controllers.BackupTable)
 
 	// 3. Управление запросами
 	r.POST("/api/queries/save", controllers.SaveQuery)
 	r.GET("/api/queries/history", controllers.GetQueryHistory)
-	r.POST("/api/queries/execute", controllers.ExecuteQuery)
+	r.POST("/api/queries/execute", middleware.RawSQLAllowlist(), controllers.ExecuteQuery)
+	r.POST("/api/queries/:id/execute", middleware.RawSQLAllowlist(), controllers.ExecuteSavedQuery)
+	r.POST("/api/queries/validate", middleware.RawSQLAllowlist(), controllers.ValidateQuery)
+	r.POST("/api/queries/describe", middleware.RawSQLAllowlist(), controllers.DescribeQuery)
+	r.PUT("/api/queries/:id", controllers.UpdateQuery)
 	r.DELETE("/api/queries/:id", controllers.DeleteQuery)
 
 	// 4. Экспорт данных
 	r.GET("/api/export/:table", controllers.ExportTable)
-	r.POST("/api/export/query", controllers.ExportQueryResults)
+	r.POST("/api/export/query", middleware.RawSQLAllowlist(), controllers.ExportQueryResults)
+	r.POST("/api/export/:table/by-query", middleware.RawSQLAllowlist(), controllers.ExportTableByQuery)
 
 	r.GET("/api/tables/:name/info", controllers.GetTableInfo)
+	r.GET("/api/tables/:name/ddl", controllers.GetTableDDL)
+	r.GET("/api/tables/:name/checksum", controllers.GetTableChecksum)
+	r.GET("/api/tables/:name/query-template", controllers.GetQueryTemplate)
+	r.GET("/api/tables/:name/jsonschema", controllers.GetTableJSONSchema)
+	r.POST("/api/tables/:name/constraints", controllers.AddConstraint)
+	r.DELETE("/api/tables/:name/constraints/:constraintName", controllers.DropConstraint)
 	r.GET("/api/tables/:name/data", controllers.GetTableData)
 
+	r.GET("/api/tables/:name/rows/:id", controllers.GetRow)
 	r.GET("/api/tables/:name/rows/:id/backup", controllers.BackupRow)
 	r.POST("/api/tables/:name/rows/restore", controllers.RestoreRow)
 	r.POST("/api/tables/:name/columns", controllers.AddColumn)
 
 	r.POST("/api/tables/:name/rows", controllers.AddRow)
+	r.POST("/api/tables/:name/rows/bulk", controllers.BulkInsertRows)
+	r.POST("/api/tables/:name/ingest", controllers.IngestRows)
+	r.POST("/api/tables/:name/rows/bulk-update", controllers.BulkUpdateRows)
 	r.PUT("/api/tables/:name/rows/:id", controllers.UpdateRow)
 	r.DELETE("/api/tables/:name/rows/:id", controllers.DeleteRow)
+	r.DELETE("/api/tables/:name/rows", controllers.DeleteAllRows)
+
+	// 5. Администрирование
+	admin := r.Group("/api/admin", middleware.AdminOnly())
+	admin.POST("/test-connection", controllers.TestConnection)
+	admin.POST("/reconnect", controllers.Reconnect)
+	admin.GET("/activity", controllers.GetActivity)
+	admin.POST("/kill/:pid", controllers.KillBackend)
+	admin.POST("/migrations/domain-columns", controllers.FixDomainColumns)
+
+	// 6. Доменные модели
+	r.GET("/api/employees", controllers.ListEmployees)
+	r.GET("/api/productions", controllers.ListProductions)
+	r.GET("/api/deliveries", controllers.ListDeliveries)
+	r.GET("/api/transports", controllers.ListTransports)
+	r.GET("/api/sales-points", controllers.ListSalesPoints)
+	r.GET("/api/products", controllers.ListProducts)
+	r.GET("/api/products/:id/deliveries", controllers.ListProductDeliveries)
+	r.POST("/api/products/:id/deliveries", controllers.AddProductDeliveries)
+	r.DELETE("/api/products/:id/deliveries/:relatedId", controllers.RemoveProductDelivery)
+	r.GET("/api/products/:id/materials", controllers.ListProductMaterials)
+	r.POST("/api/products/:id/materials", controllers.AddProductMaterials)
+	r.DELETE("/api/products/:id/materials/:relatedId", controllers.RemoveProductMaterial)
+	r.GET("/api/materials", controllers.ListMaterials)
+	r.GET("/api/reports/deliveries", controllers.DeliveriesReport)
 
 	r.Run(":8081")
 }
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+// AdminOnly пропускает запрос только с заголовком X-Admin-Token, совпадающим с ADMIN_TOKEN.
+// Если ADMIN_TOKEN не задан, административные эндпоинты недоступны
+func AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := initializers.GetConfig().AdminToken
+		provided := c.GetHeader("X-Admin-Token")
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(provided)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Доступ только для администратора"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+// TableAllowlist отклоняет запросы к таблицам вне TABLE_ALLOWLIST по параметрам пути :name и :table.
+// Таблицы из тела запроса проверяют сами обработчики, а маршруты с произвольным SQL
+// закрывает RawSQLAllowlist
+func TableAllowlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, param := range []string{"name", "table"} {
+			table := c.Param(param)
+			if table != "" && !initializers.TableAllowed(table) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": "Доступ к таблице запрещен",
+					"table": table,
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RawSQLAllowlist закрывает маршрут с произвольным SQL (выполнение, проверка, описание запросов,
+// импорт SQL), пока задан TABLE_ALLOWLIST: таблицы в тексте запроса по пути не проверить,
+// и через такой маршрут список можно было бы обойти
+func RawSQLAllowlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, _, enabled := initializers.TableAllowlist(); enabled {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Произвольный SQL недоступен при заданном TABLE_ALLOWLIST",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+func TestRawSQLAllowlist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("DB_USER", "test")
+	t.Setenv("DB_NAME", "test")
+	// Регистрируется после t.Setenv, поэтому выполняется, пока DB_USER и DB_NAME еще заданы
+	t.Cleanup(initializers.LoadConfig)
+
+	tests := []struct {
+		allowlist string
+		status    int
+	}{
+		{"", http.StatusOK},
+		{"users", http.StatusForbidden},
+		{"shop_*", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Setenv("TABLE_ALLOWLIST", tt.allowlist)
+		initializers.LoadConfig()
+
+		r := gin.New()
+		r.Use(TableAllowlist())
+		r.POST("/api/queries/execute", RawSQLAllowlist(), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/queries/execute", nil))
+		if w.Code != tt.status {
+			t.Errorf("TABLE_ALLOWLIST=%q: %d, want %d", tt.allowlist, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit ограничивает размер JSON-тела запроса maxBytes байтами. Запрос с большим
+// Content-Length отклоняется сразу с 413, тело без длины обрезается http.MaxBytesReader —
+// тогда разбор JSON в обработчике завершится ошибкой. Загрузка файлов (multipart) не ограничивается
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || !strings.HasPrefix(c.ContentType(), "application/json") {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":    "Слишком большое тело запроса",
+				"maxBytes": maxBytes,
+			})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
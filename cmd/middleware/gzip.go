@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipWriter буферизует JSON-ответ, чтобы после обработчика решить, сжимать ли его.
+// Ответы других типов (zip-архивы, CSV, текст) пишутся напрямую без буферизации
+type gzipWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	decided   bool
+	buffering bool
+}
+
+func (w *gzipWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.buffering = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.buf.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Gzip сжимает JSON-ответы размером от minSize байт, если клиент принимает gzip
+func Gzip(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &gzipWriter{ResponseWriter: original}
+		c.Writer = w
+		c.Next()
+		c.Writer = original
+
+		if !w.buffering {
+			return
+		}
+
+		header := original.Header()
+		header.Add("Vary", "Accept-Encoding")
+		if w.buf.Len() < minSize {
+			original.Write(w.buf.Bytes())
+			return
+		}
+
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write(w.buf.Bytes())
+		gz.Close()
+
+		header.Set("Content-Encoding", "gzip")
+		header.Set("Content-Length", strconv.Itoa(compressed.Len()))
+		original.Write(compressed.Bytes())
+	}
+}
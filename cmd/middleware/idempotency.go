@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"server/initializers"
+	"server/model"
+)
+
+// recordingWriter копирует тело ответа, чтобы сохранить его для повторных запросов
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// compressBody сжимает тело ответа для хранения
+func compressBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// savedBody возвращает сохраненное тело ответа, распаковывая его при необходимости
+func savedBody(saved model.IdempotencyKey) ([]byte, error) {
+	if saved.BodyGzip == nil {
+		return []byte(saved.Body), nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(saved.BodyGzip))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// idempotencyPendingTimeout — через сколько незавершенная запись считается брошенной
+// (процесс упал, не дождавшись ответа), и ключ можно использовать снова
+const idempotencyPendingTimeout = 10 * time.Minute
+
+// idempotencyPurgeInterval — как часто удаляются просроченные ключи
+const idempotencyPurgeInterval = time.Minute
+
+// lastIdempotencyPurge — время последней очистки (unix-секунды)
+var lastIdempotencyPurge atomic.Int64
+
+// requestFingerprint — SHA-256 тела запроса: повтор с тем же ключом должен совпадать с оригиналом
+func requestFingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// expiredKeys отбирает просроченные записи и брошенные незавершенные
+func expiredKeys(db *gorm.DB, ttl time.Duration) *gorm.DB {
+	now := time.Now()
+	return db.Where("created_at <= ? OR (status = 0 AND created_at <= ?)",
+		now.Add(-ttl), now.Add(-idempotencyPendingTimeout))
+}
+
+// purgeExpiredKeys удаляет просроченные ключи не чаще раза в idempotencyPurgeInterval
+func purgeExpiredKeys(db *gorm.DB, ttl time.Duration) {
+	now := time.Now().Unix()
+	last := lastIdempotencyPurge.Load()
+	if now-last < int64(idempotencyPurgeInterval/time.Second) ||
+		!lastIdempotencyPurge.CompareAndSwap(last, now) {
+		return
+	}
+	expiredKeys(db, ttl).Delete(&model.IdempotencyKey{})
+}
+
+// Idempotency сохраняет ответы изменяющих запросов с заголовком Idempotency-Key на время ttl.
+// Повторный запрос с тем же ключом на тот же маршрут получает сохраненный ответ без повторного выполнения.
+// Ключ занимается записью до выполнения запроса (уникальный индекс по ключу и маршруту), поэтому
+// из одновременных запросов выполняется только первый, остальные получают 409, пока он не завершится.
+// Повтор с другим телом запроса получает 422.
+// Ответы с ошибкой сервера (5xx) не сохраняются, чтобы запрос можно было повторить.
+// Ответы от compressMin байт хранятся сжатыми; compressMin <= 0 отключает сжатие
+func Idempotency(ttl time.Duration, compressMin int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения тела запроса", "details": err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		db := initializers.GetDB()
+		route := c.Request.Method + " " + c.Request.URL.Path
+		fingerprint := requestFingerprint(body)
+
+		purgeExpiredKeys(db, ttl)
+		expiredKeys(db.Where("key = ? AND route = ?", key, route), ttl).Delete(&model.IdempotencyKey{})
+
+		// Занимаем ключ до выполнения запроса
+		record := model.IdempotencyKey{Key: key, Route: route, Fingerprint: fingerprint}
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":   "Ошибка сохранения ключа идемпотентности",
+				"details": result.Error.Error(),
+			})
+			return
+		}
+		if result.RowsAffected == 0 {
+			replayIdempotent(c, db, key, route, fingerprint)
+			return
+		}
+
+		// Ключ освобождается, если ответ не сохраняется или обработчик паникует
+		saved := false
+		defer func() {
+			if !saved {
+				db.Delete(&model.IdempotencyKey{}, record.ID)
+			}
+		}()
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		// Сохраняем только JSON-ответы: файлы (архивы, CSV) повторно не отдаем
+		if w.Status() >= http.StatusInternalServerError ||
+			!strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			return
+		}
+
+		updates := map[string]interface{}{
+			"status":    w.Status(),
+			"body":      w.body.String(),
+			"body_gzip": nil,
+		}
+		if compressMin > 0 && w.body.Len() >= compressMin {
+			if compressed, err := compressBody(w.body.Bytes()); err == nil {
+				updates["body"] = ""
+				updates["body_gzip"] = compressed
+			}
+		}
+		saved = db.Model(&record).Updates(updates).Error == nil
+	}
+}
+
+// replayIdempotent отвечает на запрос, ключ которого уже занят: сохраненным ответом,
+// 409 — если первый запрос еще выполняется, 422 — если тело запроса другое
+func replayIdempotent(c *gin.Context, db *gorm.DB, key, route, fingerprint string) {
+	var saved model.IdempotencyKey
+	if err := db.Where("key = ? AND route = ?", key, route).First(&saved).Error; err != nil {
+		// Запись удалили между вставкой и чтением (ответ не сохранился) — запрос можно повторить
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Запрос с этим Idempotency-Key еще выполняется"})
+		return
+	}
+
+	// У записей, сохраненных до появления отпечатков, Fingerprint пустой
+	if saved.Fingerprint != "" && saved.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Idempotency-Key уже использован с другим телом запроса",
+		})
+		return
+	}
+	if saved.Status == 0 {
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Запрос с этим Idempotency-Key еще выполняется"})
+		return
+	}
+
+	body, err := savedBody(saved)
+	if err != nil {
+		// Поврежденную запись удаляем: повторный запрос выполнится заново
+		db.Delete(&model.IdempotencyKey{}, saved.ID)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":   "Не удалось прочитать сохраненный ответ, повторите запрос",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(saved.Status, "application/json; charset=utf-8", body)
+	c.Abort()
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"server/initializers"
+	"server/model"
+)
+
+func TestRequestFingerprint(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{`{"name":"a"}`, `{"name":"a"}`, true},
+		{`{"name":"a"}`, `{"name":"b"}`, false},
+		{``, ``, true},
+		{``, `{}`, false},
+	}
+	for _, tt := range tests {
+		if got := requestFingerprint([]byte(tt.a)) == requestFingerprint([]byte(tt.b)); got != tt.same {
+			t.Errorf("%q и %q: совпадение %v, want %v", tt.a, tt.b, got, tt.same)
+		}
+	}
+}
+
+// idempotencyTestDB подключается к БД из TEST_DATABASE_URL; без нее тест пропускается
+func idempotencyTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL не задан")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.IdempotencyKey{}); err != nil {
+		t.Fatal(err)
+	}
+	initializers.SwapDB(db, initializers.DBConfig{})
+	return db
+}
+
+// TestIdempotencyConcurrentDuplicates проверяет, что из одновременных запросов с одним ключом
+// обработчик выполняется один раз, а повтор с другим телом получает 422
+func TestIdempotencyConcurrentDuplicates(t *testing.T) {
+	db := idempotencyTestDB(t)
+	key := "test-" + time.Now().Format(time.RFC3339Nano)
+	t.Cleanup(func() { db.Where("key = ?", key).Delete(&model.IdempotencyKey{}) })
+
+	gin.SetMode(gin.TestMode)
+	var calls atomic.Int32
+	r := gin.New()
+	r.Use(Idempotency(time.Hour, 0))
+	r.POST("/api/items", func(c *gin.Context) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		c.JSON(http.StatusCreated, gin.H{"status": "ok"})
+	})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/items", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = send(`{"name":"a"}`).Code
+		}(i)
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("обработчик выполнен %d раз, want 1 (ответы %v)", n, codes)
+	}
+	for _, code := range codes {
+		if code != http.StatusCreated && code != http.StatusConflict {
+			t.Fatalf("ответы %v, want 201 или 409", codes)
+		}
+	}
+
+	if w := send(`{"name":"a"}`); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("повтор: %d %s, want сохраненный 201", w.Code, w.Body.String())
+	}
+	if w := send(`{"name":"b"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("другое тело: %d %s, want 422", w.Code, w.Body.String())
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("обработчик выполнен %d раз после повторов, want 1", n)
+	}
+}
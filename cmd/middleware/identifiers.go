@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+// identifierParams — параметры пути с именами таблиц и колонок
+var identifierParams = map[string]bool{"name": true, "table": true, "column": true}
+
+// NormalizeIdentifiers приводит имена таблиц и колонок в параметрах пути к политике
+// IDENTIFIER_CASE, чтобы поиск совпадал с тем, как имена были созданы
+func NormalizeIdentifiers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for i, param := range c.Params {
+			if identifierParams[param.Key] {
+				c.Params[i].Value = initializers.NormalizeIdentifier(param.Value)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"server/initializers"
+)
+
+func TestNormalizeIdentifiers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("DB_USER", "test")
+	t.Setenv("DB_NAME", "test")
+	// Регистрируется после t.Setenv, поэтому выполняется, пока DB_USER и DB_NAME еще заданы
+	t.Cleanup(initializers.LoadConfig)
+
+	tests := []struct {
+		policy     string
+		wantName   string
+		wantColumn string
+	}{
+		{initializers.IdentifierCaseLower, "mytable", "somecolumn"},
+		{initializers.IdentifierCasePreserve, "MyTable", "SomeColumn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			t.Setenv("IDENTIFIER_CASE", tt.policy)
+			initializers.LoadConfig()
+
+			var name, column, id string
+			r := gin.New()
+			r.Use(NormalizeIdentifiers())
+			r.GET("/api/tables/:name/columns/:column/:id", func(c *gin.Context) {
+				name, column, id = c.Param("name"), c.Param("column"), c.Param("id")
+			})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/tables/MyTable/columns/SomeColumn/AbC", nil))
+
+			if name != tt.wantName || column != tt.wantColumn {
+				t.Fatalf("params = %q, %q; want %q, %q", name, column, tt.wantName, tt.wantColumn)
+			}
+			if id != "AbC" {
+				t.Fatalf("id = %q: параметры, не являющиеся именами, не должны меняться", id)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"server/initializers"
+)
+
+// PrimaryHeader — заголовок, которым клиент направляет все запросы GET на основной сервер
+// вместо реплики, чтобы сразу увидеть свои изменения (X-DB-Primary: true)
+const PrimaryHeader = "X-DB-Primary"
+
+// StatementTimeoutHeader — заголовок, которым клиент может задать свой предел времени
+// запросов к БД в миллисекундах, не больше максимума сервера
+const StatementTimeoutHeader = "X-Statement-Timeout"
+
+// RequestDB создает для каждого запроса сессию GORM, привязанную к контексту запроса,
+// и кладет ее в gin.Context. Обработчики получают ее через getDB(c); сюда же добавляются
+// настройки уровня запроса (таймауты, режим только для чтения).
+// timeout — предел statement_timeout для транзакций запроса (0 — без предела),
+// заголовок X-Statement-Timeout может задать другой предел до maxTimeout.
+// Если подключена реплика, ее используют только GET и HEAD без X-DB-Primary: true;
+// узлы, выполнившие запросы, возвращаются в заголовке X-DB-Node
+func RequestDB(timeout, maxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestTimeout := timeout
+		if raw := c.GetHeader(StatementTimeoutHeader); raw != "" {
+			ms, err := strconv.Atoi(raw)
+			if err != nil || ms < 1 || time.Duration(ms)*time.Millisecond > maxTimeout {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error":     "Некорректный " + StatementTimeoutHeader,
+					"received":  raw,
+					"maxMillis": maxTimeout.Milliseconds(),
+				})
+				return
+			}
+			requestTimeout = time.Duration(ms) * time.Millisecond
+		}
+
+		ctx := c.Request.Context()
+		if requestTimeout > 0 {
+			ctx = initializers.WithStatementTimeout(ctx, requestTimeout)
+		}
+		base := initializers.GetDB()
+		if initializers.HasReplica() {
+			ctx = initializers.WithDBNodeTracking(ctx, c.Writer.Header())
+			readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+			if !readOnly || c.GetHeader(PrimaryHeader) == "true" {
+				base = initializers.UsePrimary(base)
+			}
+		}
+		db := base.Session(&gorm.Session{
+			Context: ctx,
+		})
+		c.Set(initializers.RequestDBKey, db)
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package initializers
+
+import (
+	"strings"
+)
+
+// TableAllowlist разбирает TABLE_ALLOWLIST: имена таблиц через запятую,
+// элемент со звездочкой на конце (например, "shop_*") задает префикс.
+// enabled=false, если переменная не задана — тогда доступны все таблицы
+func TableAllowlist() (names, prefixes []string, enabled bool) {
+	raw := GetConfig().TableAllowlist
+	if raw == "" {
+		return nil, nil, false
+	}
+
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+		case strings.HasSuffix(item, "*"):
+			prefixes = append(prefixes, strings.TrimSuffix(item, "*"))
+		default:
+			names = append(names, item)
+		}
+	}
+	return names, prefixes, true
+}
+
+// TableAllowed проверяет, разрешена ли таблица списком TABLE_ALLOWLIST
+func TableAllowed(table string) bool {
+	names, prefixes, enabled := TableAllowlist()
+	if !enabled {
+		return true
+	}
+	for _, name := range names {
+		if table == name {
+			return true
+		}
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(table, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package initializers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config — настройки приложения из переменных окружения. Читаются один раз при старте
+// (LoadConfig); параметры подключения к БД перечитываются при переподключении, см. ConfigFromEnv
+type Config struct {
+	DB DBConfig // DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME, DB_REPLICA_URL
+
+	AdminToken string // ADMIN_TOKEN; пустой — административные эндпоинты закрыты
+
+	ReadOnlyMode        bool // READ_ONLY_MODE: выполнение, проверка и импорт SQL — только SELECT
+	EnforceSelectLimit  bool // ENFORCE_SELECT_LIMIT: SELECT без LIMIT отклоняется
+	MaxResultRows       int  // MAX_RESULT_ROWS: предел строк результата запроса
+	MaxResultRowsStrict bool // MAX_RESULT_ROWS_STRICT: превышение предела — ошибка, а не обрезка
+
+	IdentifierMaxLength     int           // IDENTIFIER_MAX_LENGTH: предел длины имен таблиц и колонок, 1..63
+	IdentifierCase          string        // IDENTIFIER_CASE: lower (по умолчанию) или preserve, см. NormalizeIdentifier
+	SchemaLock              bool          // SCHEMA_LOCK: изменения структуры одной таблицы выполняются по очереди
+	SchemaLockWait          time.Duration // SCHEMA_LOCK_WAIT_MS: сколько ждать блокировку, затем 409
+	RequirePrimaryKey       bool          // REQUIRE_PRIMARY_KEY: CreateTable отклоняет таблицы без первичного ключа
+	CreateTableMaxColumns   int           // CREATE_TABLE_MAX_COLUMNS
+	CreateTableMaxSQLLength int           // CREATE_TABLE_MAX_SQL_LENGTH, байт
+
+	ExportMaxRows    int   // EXPORT_MAX_ROWS: предел строк CSV-выгрузки, ?maxRows= может только уменьшить
+	SQLImportMaxSize int64 // SQL_IMPORT_MAX_SIZE: предел размера SQL-скрипта для импорта, байт
+	IngestBatchSize  int   // INGEST_BATCH_SIZE: строк в одном INSERT при потоковой загрузке NDJSON
+	IngestMaxLine    int   // INGEST_MAX_LINE_SIZE: предел длины одной строки NDJSON, байт
+
+	GzipMinSize     int   // GZIP_MIN_SIZE: ответы меньше не сжимаются, байт
+	MaxJSONBodySize int64 // MAX_JSON_BODY_SIZE, байт
+
+	IdempotencyTTL         time.Duration // IDEMPOTENCY_TTL_HOURS
+	IdempotencyGzip        bool          // IDEMPOTENCY_GZIP
+	IdempotencyGzipMinSize int           // IDEMPOTENCY_GZIP_MIN_SIZE, байт
+
+	StatementTimeout    time.Duration // STATEMENT_TIMEOUT_MS: предел запроса к БД в транзакции, 0 — без предела
+	StatementTimeoutMax time.Duration // STATEMENT_TIMEOUT_MAX_MS: максимум для заголовка X-Statement-Timeout
+
+	DBHealthInterval   time.Duration // DB_HEALTH_INTERVAL_SEC: период проверки подключения, 0 — не проверять
+	DBReconnectRetries int           // DB_RECONNECT_RETRIES: попыток переподключения за одну проверку
+	DBReconnectBackoff time.Duration // DB_RECONNECT_BACKOFF_MS: пауза перед второй попыткой, дальше удваивается
+
+	DBLogLevel  string        // DB_LOG_LEVEL: silent, error, warn, info
+	DBSlowQuery time.Duration // DB_SLOW_QUERY_MS
+
+	DebugSQLResponses bool // DEBUG_SQL_RESPONSES: разрешает ?debugSql=true в DDL-эндпоинтах
+
+	MigrateDomainColumns bool // MIGRATE_DOMAIN_COLUMNS: Migrate переименовывает колонки доменных моделей при старте
+
+	TableAllowlist  string // TABLE_ALLOWLIST, см. TableAllowlist()
+	FieldValidators string // FIELD_VALIDATORS, см. FieldValidators()
+}
+
+var (
+	config   Config
+	configMu sync.RWMutex
+)
+
+// GetConfig возвращает копию текущих настроек
+func GetConfig() Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config
+}
+
+// envReader читает переменные окружения и копит ошибки, чтобы сообщить обо всех сразу
+type envReader struct {
+	errs []string
+}
+
+func (r *envReader) str(name, def string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return strings.TrimSpace(value)
+	}
+	return def
+}
+
+// required читает обязательную переменную; пустое значение — ошибка
+func (r *envReader) required(name string) string {
+	value := r.str(name, "")
+	if value == "" {
+		r.errs = append(r.errs, fmt.Sprintf("%s: переменная обязательна", name))
+	}
+	return value
+}
+
+func (r *envReader) int(name string, def, min, max int) int {
+	raw, ok := os.LookupEnv(name)
+	if !ok || strings.TrimSpace(raw) == "" {
+		return def
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		r.errs = append(r.errs, fmt.Sprintf("%s: ожидается целое число, получено '%s'", name, raw))
+		return def
+	}
+	if value < min || value > max {
+		r.errs = append(r.errs, fmt.Sprintf("%s: значение %d вне диапазона %d..%d", name, value, min, max))
+		return def
+	}
+	return value
+}
+
+func (r *envReader) bool(name string, def bool) bool {
+	raw, ok := os.LookupEnv(name)
+	if !ok || strings.TrimSpace(raw) == "" {
+		return def
+	}
+	value, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		r.errs = append(r.errs, fmt.Sprintf("%s: ожидается true или false, получено '%s'", name, raw))
+		return def
+	}
+	return value
+}
+
+func (r *envReader) oneOf(name, def string, allowed ...string) string {
+	value := strings.ToLower(r.str(name, def))
+	if value == "" {
+		return def
+	}
+	for _, a := range allowed {
+		if value == a {
+			return value
+		}
+	}
+	r.errs = append(r.errs, fmt.Sprintf("%s: допустимые значения %s, получено '%s'", name, strings.Join(allowed, ", "), value))
+	return def
+}
+
+// readDBConfig читает параметры подключения к БД. DB_PASSWORD может быть пустым,
+// например при trust-аутентификации
+func readDBConfig(r *envReader) DBConfig {
+	host := r.str("DB_HOST", "")
+	if host == "" {
+		host = "localhost"
+	}
+	return DBConfig{
+		Host:       host,
+		Port:       strconv.Itoa(r.int("DB_PORT", 5432, 1, 65535)),
+		User:       r.required("DB_USER"),
+		Password:   r.str("DB_PASSWORD", ""),
+		Name:       r.required("DB_NAME"),
+		ReplicaURL: r.str("DB_REPLICA_URL", ""),
+	}
+}
+
+// ParseConfig читает настройки из окружения. Возвращает ошибку со списком всех
+// некорректных переменных
+func ParseConfig() (Config, error) {
+	const maxInt = int(^uint(0) >> 1)
+	r := &envReader{}
+
+	cfg := Config{
+		DB: readDBConfig(r),
+
+		AdminToken: r.str("ADMIN_TOKEN", ""),
+
+		ReadOnlyMode:        r.bool("READ_ONLY_MODE", false),
+		EnforceSelectLimit:  r.bool("ENFORCE_SELECT_LIMIT", false),
+		MaxResultRows:       r.int("MAX_RESULT_ROWS", 100000, 1, maxInt),
+		MaxResultRowsStrict: r.bool("MAX_RESULT_ROWS_STRICT", false),
+
+		IdentifierMaxLength:     r.int("IDENTIFIER_MAX_LENGTH", 63, 1, 63),
+		IdentifierCase:          r.oneOf("IDENTIFIER_CASE", IdentifierCaseLower, IdentifierCasePreserve, IdentifierCaseLower),
+		SchemaLock:              r.bool("SCHEMA_LOCK", true),
+		SchemaLockWait:          time.Duration(r.int("SCHEMA_LOCK_WAIT_MS", 2000, 0, 600000)) * time.Millisecond,
+		RequirePrimaryKey:       r.bool("REQUIRE_PRIMARY_KEY", true),
+		CreateTableMaxColumns:   r.int("CREATE_TABLE_MAX_COLUMNS", 200, 1, 1600),
+		CreateTableMaxSQLLength: r.int("CREATE_TABLE_MAX_SQL_LENGTH", 64*1024, 1, maxInt),
+
+		ExportMaxRows:    r.int("EXPORT_MAX_ROWS", 1000000, 1, maxInt),
+		SQLImportMaxSize: int64(r.int("SQL_IMPORT_MAX_SIZE", 10<<20, 1, maxInt)),
+		IngestBatchSize:  r.int("INGEST_BATCH_SIZE", 500, 1, 10000),
+		IngestMaxLine:    r.int("INGEST_MAX_LINE_SIZE", 1<<20, 1024, maxInt),
+
+		GzipMinSize:     r.int("GZIP_MIN_SIZE", 1024, 0, maxInt),
+		MaxJSONBodySize: int64(r.int("MAX_JSON_BODY_SIZE", 1<<20, 0, maxInt)),
+
+		IdempotencyTTL:         time.Duration(r.int("IDEMPOTENCY_TTL_HOURS", 24, 1, 24*365)) * time.Hour,
+		IdempotencyGzip:        r.bool("IDEMPOTENCY_GZIP", false),
+		IdempotencyGzipMinSize: r.int("IDEMPOTENCY_GZIP_MIN_SIZE", 4096, 1, maxInt),
+
+		StatementTimeout:    time.Duration(r.int("STATEMENT_TIMEOUT_MS", 30000, 0, maxInt)) * time.Millisecond,
+		StatementTimeoutMax: time.Duration(r.int("STATEMENT_TIMEOUT_MAX_MS", 300000, 1, maxInt)) * time.Millisecond,
+
+		DBHealthInterval:   time.Duration(r.int("DB_HEALTH_INTERVAL_SEC", 15, 0, 24*3600)) * time.Second,
+		DBReconnectRetries: r.int("DB_RECONNECT_RETRIES", 5, 1, 100),
+		DBReconnectBackoff: time.Duration(r.int("DB_RECONNECT_BACKOFF_MS", 500, 1, 60000)) * time.Millisecond,
+
+		DBLogLevel:  r.oneOf("DB_LOG_LEVEL", "warn", "silent", "error", "warn", "info"),
+		DBSlowQuery: time.Duration(r.int("DB_SLOW_QUERY_MS", 200, 0, maxInt)) * time.Millisecond,
+
+		DebugSQLResponses: r.bool("DEBUG_SQL_RESPONSES", false),
+
+		MigrateDomainColumns: r.bool("MIGRATE_DOMAIN_COLUMNS", false),
+
+		TableAllowlist:  r.str("TABLE_ALLOWLIST", ""),
+		FieldValidators: r.str("FIELD_VALIDATORS", defaultFieldValidators),
+	}
+
+	if cfg.StatementTimeout > cfg.StatementTimeoutMax {
+		r.errs = append(r.errs, "STATEMENT_TIMEOUT_MS: не может быть больше STATEMENT_TIMEOUT_MAX_MS")
+	}
+
+	if len(r.errs) > 0 {
+		return cfg, fmt.Errorf("некорректные настройки:\n  %s", strings.Join(r.errs, "\n  "))
+	}
+	return cfg, nil
+}
+
+// LoadConfig читает настройки при старте и завершает процесс, если они некорректны
+func LoadConfig() {
+	cfg, err := ParseConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	configMu.Lock()
+	config = cfg
+	configMu.Unlock()
+}
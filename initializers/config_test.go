@@ -0,0 +1,73 @@
+package initializers
+
+import (
+	"strings"
+	"testing"
+)
+
+// setRequiredEnv задает обязательные переменные, без которых ParseConfig возвращает ошибку
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("DB_USER", "app")
+	t.Setenv("DB_NAME", "app")
+}
+
+func TestParseConfigDB(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("DB_HOST", "")
+		t.Setenv("DB_PORT", "")
+		t.Setenv("DB_PASSWORD", "")
+
+		cfg, err := ParseConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := DBConfig{Host: "localhost", Port: "5432", User: "app", Name: "app"}
+		if cfg.DB != want {
+			t.Fatalf("DB = %+v, want %+v", cfg.DB, want)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"missing user", map[string]string{"DB_USER": ""}, "DB_USER"},
+		{"missing name", map[string]string{"DB_NAME": " "}, "DB_NAME"},
+		{"bad port", map[string]string{"DB_PORT": "postgres"}, "DB_PORT"},
+		{"port out of range", map[string]string{"DB_PORT": "70000"}, "DB_PORT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			_, err := ParseConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseConfig() error = %v, want mention of %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("DB_PORT", "6432")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Host != "db.internal" || cfg.Port != "6432" || cfg.User != "app" {
+		t.Fatalf("ConfigFromEnv() = %+v", cfg)
+	}
+
+	t.Setenv("DB_NAME", "")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Fatal("ConfigFromEnv() accepted empty DB_NAME")
+	}
+}
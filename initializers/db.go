@@ -1,50 +1,243 @@
 package initializers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"io/fs"
 	"log"
-	"os"
+	"server/model"
+	"strings"
+	"sync"
+	"time"
 )
 
 var DB *gorm.DB
 
-func LoadEnv() {
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Fatal("Error loading .env file")
+// dbMu защищает DB от одновременной замены при переподключении
+var dbMu sync.RWMutex
+
+// RequestDBKey — ключ gin.Context, под которым middleware.RequestDB хранит подключение запроса
+const RequestDBKey = "requestDB"
+
+type statementTimeoutKey struct{}
+
+// WithStatementTimeout сохраняет в контексте предел времени выполнения запросов к БД;
+// его применяет BeginTx через SET LOCAL statement_timeout
+func WithStatementTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, statementTimeoutKey{}, timeout)
+}
+
+// StatementTimeout возвращает предел из контекста, 0 — не задан
+func StatementTimeout(ctx context.Context) time.Duration {
+	if ctx == nil {
+		return 0
 	}
+	timeout, _ := ctx.Value(statementTimeoutKey{}).(time.Duration)
+	return timeout
 }
 
-func ConnectEnv() {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
+// BeginTx начинает транзакцию и, если в контексте db задан предел (WithStatementTimeout),
+// ограничивает ее запросы через SET LOCAL statement_timeout — Postgres отменит их сам
+func BeginTx(db *gorm.DB) *gorm.DB {
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx
+	}
+	if timeout := StatementTimeout(db.Statement.Context); timeout > 0 {
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())).Error; err != nil {
+			tx.Rollback()
+			tx.AddError(err)
+		}
+	}
+	return tx
+}
+
+// GetDB возвращает текущее подключение к БД
+func GetDB() *gorm.DB {
+	dbMu.RLock()
+	defer dbMu.RUnlock()
+	return DB
+}
+
+// dbConfig — параметры текущего подключения; по ним WatchDB восстанавливает пул
+var dbConfig DBConfig
+
+// poolCloseGrace — сколько замененный пул остается открытым: HTTP-запросы, получившие его
+// до замены (см. middleware.RequestDB), успевают выполнить следующие запросы к БД
+var poolCloseGrace = 5 * time.Second
+
+// poolClosePoll — период проверки занятых подключений замененного пула
+const poolClosePoll = 200 * time.Millisecond
+
+// closePoolWhenIdle закрывает замененный пул в фоне: не раньше poolCloseGrace и когда в нем
+// не останется занятых подключений, но не позже maxWait после grace. Свободные подключения
+// закрываются сразу
+func closePoolWhenIdle(sqlDB *sql.DB, maxWait time.Duration) {
+	sqlDB.SetMaxIdleConns(0)
+	go func() {
+		time.Sleep(poolCloseGrace)
+		deadline := time.Now().Add(maxWait)
+		for sqlDB.Stats().InUse > 0 && time.Now().Before(deadline) {
+			time.Sleep(poolClosePoll)
+		}
+		sqlDB.Close()
+	}()
+}
+
+// SwapDB заменяет текущее подключение на новое, открытое с параметрами cfg. Старые пулы
+// (основной и реплики) закрываются в фоне, когда запросы на них завершатся (см. closePoolWhenIdle):
+// закрытие сразу обрывало бы запросы ошибкой "sql: database is closed".
+// Если задан cfg.ReplicaURL, к новому подключению добавляется реплика для чтения (см. attachReplica)
+func SwapDB(db *gorm.DB, cfg DBConfig) {
+	replica := attachReplica(db, cfg.ReplicaURL)
+
+	dbMu.Lock()
+	old, oldReplica := DB, dbReplica
+	DB, dbReplica = db, replica
+	dbConfig = cfg
+	dbMu.Unlock()
+
+	// Дольше STATEMENT_TIMEOUT_MAX_MS запрос к БД выполняться не может
+	maxWait := GetConfig().StatementTimeoutMax
+	if old != nil {
+		if sqlDB, err := old.DB(); err == nil {
+			closePoolWhenIdle(sqlDB, maxWait)
+		}
+	}
+	if oldReplica != nil {
+		closePoolWhenIdle(oldReplica, maxWait)
+	}
+}
+
+// DBConfig — параметры подключения к PostgreSQL
+type DBConfig struct {
+	Host     string `json:"host"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Name     string `json:"dbname"`
+	Port     string `json:"port"`
+	SSLMode  string `json:"sslmode"`
+
+	// ReplicaURL — строка подключения к реплике для чтения (DB_REPLICA_URL), пустая — без реплики
+	ReplicaURL string `json:"replicaUrl,omitempty"`
+}
+
+// ConfigFromEnv заново читает параметры подключения из окружения — например, после ReloadEnv.
+// При старте они читаются вместе с остальными настройками в Config.DB.
+// DB_HOST и DB_PORT по умолчанию localhost и 5432
+func ConfigFromEnv() (DBConfig, error) {
+	r := &envReader{}
+	cfg := readDBConfig(r)
+	if len(r.errs) > 0 {
+		return cfg, fmt.Errorf("некорректные параметры подключения: %s", strings.Join(r.errs, "; "))
+	}
+	return cfg, nil
+}
+
+// DSN формирует строку подключения; значения экранируются по правилам libpq
+func (cfg DBConfig) DSN() string {
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	quote := func(s string) string {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+	}
+
+	return fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		quote(cfg.Host),
+		quote(cfg.User),
+		quote(cfg.Password),
+		quote(cfg.Name),
+		quote(cfg.Port),
+		quote(sslMode),
 	)
+}
+
+// Open открывает новое подключение и проверяет его Ping с ограничением по времени.
+// Глобальный DB не изменяется
+func Open(cfg DBConfig, timeout time.Duration) (*gorm.DB, error) {
+	dsn := cfg.DSN()
+	if timeout > 0 {
+		dsn += fmt.Sprintf(" connect_timeout=%d", int(timeout.Seconds()+0.5))
+	}
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		SkipDefaultTransaction: true,
+		Logger:                 NewGormLogger(),
 	})
 	if err != nil {
-		log.Fatal("Failed to connect to database: ", err)
+		return nil, err
 	}
 
-	// Проверяем подключение
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("Failed to get DB instance: ", err)
+		return nil, err
 	}
 
-	if err := sqlDB.Ping(); err != nil {
-		log.Fatal("Failed to ping database: ", err)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// LoadEnv загружает .env, если он есть. Без файла используется окружение процесса
+// (например, в контейнере); ошибка разбора существующего файла завершает процесс
+func LoadEnv() {
+	err := godotenv.Load(".env")
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Println("Warning: .env file not found, using process environment")
+		return
+	}
+	if err != nil {
+		log.Fatal("Error loading .env file: ", err)
+	}
+}
+
+// ReloadEnv перечитывает .env, перезаписывая уже загруженные переменные
+func ReloadEnv() error {
+	return godotenv.Overload(".env")
+}
+
+// ConnectEnv подключается к БД с параметрами из Config.DB; они проверены в LoadConfig
+func ConnectEnv() {
+	cfg := GetConfig().DB
+	db, err := Open(cfg, 0)
+	if err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+	SwapDB(db, cfg)
+
 	log.Println("Successfully connected to database!")
 }
+
+// Migrate создает/обновляет служебные таблицы приложения. С MIGRATE_DOMAIN_COLUMNS=true
+// также переименовывает колонки доменных моделей со старыми именами (см. DomainColumnRenames)
+func Migrate() {
+	if err := GetDB().AutoMigrate(&model.TableMeta{}, &model.SavedQuery{}, &model.AccessLog{}, &model.AuditLog{}, &model.IdempotencyKey{}); err != nil {
+		log.Fatal("Failed to migrate service tables: ", err)
+	}
+	if !GetConfig().MigrateDomainColumns {
+		return
+	}
+	if err := migrateDomainColumns(GetDB()); err != nil {
+		log.Fatal("Failed to rename domain columns: ", err)
+	}
+}
@@ -0,0 +1,38 @@
+package initializers
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// TestClosePoolWhenIdle проверяет, что замененный пул принимает запросы в течение
+// poolCloseGrace и закрывается после него
+func TestClosePoolWhenIdle(t *testing.T) {
+	grace := poolCloseGrace
+	poolCloseGrace = 100 * time.Millisecond
+	t.Cleanup(func() { poolCloseGrace = grace })
+
+	// Подключение к закрытому порту: Ping вернет ошибку, но пока пул открыт — не "database is closed"
+	pool, err := sql.Open("pgx", "postgres://user@127.0.0.1:1/db?connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := func() bool {
+		err := pool.Ping()
+		return err != nil && err.Error() == "sql: database is closed"
+	}
+
+	closePoolWhenIdle(pool, time.Second)
+	if closed() {
+		t.Fatal("пул закрыт до истечения poolCloseGrace")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !closed() {
+		if time.Now().After(deadline) {
+			t.Fatal("пул не закрыт после poolCloseGrace")
+		}
+		time.Sleep(poolClosePoll)
+	}
+}
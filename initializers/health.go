@@ -0,0 +1,119 @@
+package initializers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// healthPingTimeout — сколько ждем ответа БД при проверке и при переподключении
+const healthPingTimeout = 5 * time.Second
+
+// DBHealth — последнее известное состояние подключения к БД
+type DBHealth struct {
+	Healthy       bool       `json:"healthy"`
+	CheckedAt     time.Time  `json:"checkedAt"`
+	Error         string     `json:"error,omitempty"`
+	Failures      int        `json:"failures"` // проверок подряд с ошибкой
+	ReconnectedAt *time.Time `json:"reconnectedAt,omitempty"`
+}
+
+var (
+	dbHealth   = DBHealth{Healthy: true}
+	dbHealthMu sync.RWMutex
+)
+
+// GetDBHealth возвращает результат последней проверки WatchDB
+func GetDBHealth() DBHealth {
+	dbHealthMu.RLock()
+	defer dbHealthMu.RUnlock()
+	return dbHealth
+}
+
+func setDBHealth(update func(h *DBHealth)) {
+	dbHealthMu.Lock()
+	defer dbHealthMu.Unlock()
+	update(&dbHealth)
+}
+
+// pingDB проверяет текущий пул
+func pingDB() error {
+	sqlDB, err := GetDB().DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), healthPingTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// reconnectDB пытается открыть новый пул с параметрами текущего подключения:
+// до retries попыток, пауза между ними начинается с backoff и удваивается
+func reconnectDB(retries int, backoff time.Duration) error {
+	dbMu.RLock()
+	cfg := dbConfig
+	dbMu.RUnlock()
+
+	var err error
+	for attempt := 1; attempt <= retries; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		db, openErr := Open(cfg, healthPingTimeout)
+		if openErr == nil {
+			SwapDB(db, cfg)
+			return nil
+		}
+		err = openErr
+		log.Printf("Database reconnect attempt %d/%d failed: %v", attempt, retries, err)
+	}
+	return err
+}
+
+// checkDB проверяет подключение и при ошибке пересоздает пул
+func checkDB(retries int, backoff time.Duration) {
+	err := pingDB()
+	reconnected := false
+	if err != nil {
+		log.Printf("Database ping failed: %v, reconnecting", err)
+		if err = reconnectDB(retries, backoff); err == nil {
+			reconnected = true
+			log.Println("Database connection restored")
+		}
+	}
+
+	setDBHealth(func(h *DBHealth) {
+		now := time.Now()
+		h.CheckedAt = now
+		h.Healthy = err == nil
+		if err != nil {
+			h.Error = err.Error()
+			h.Failures++
+			return
+		}
+		h.Error = ""
+		h.Failures = 0
+		if reconnected {
+			h.ReconnectedAt = &now
+		}
+	})
+}
+
+// WatchDB запускает фоновую проверку подключения раз в interval. Если Ping не проходит,
+// пул пересоздается (см. reconnectDB), чтобы после перезапуска БД сервис восстановился сам.
+// interval <= 0 отключает проверку
+func WatchDB(interval time.Duration, retries int, backoff time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			checkDB(retries, backoff)
+		}
+	}()
+}
@@ -0,0 +1,24 @@
+package initializers
+
+import "strings"
+
+// Политики регистра имен таблиц и колонок (IDENTIFIER_CASE):
+//   - preserve: имя используется как передано и всегда экранируется кавычками,
+//     поэтому MyTable и mytable — разные таблицы;
+//   - lower (по умолчанию): имя приводится к нижнему регистру при создании, изменении
+//     и поиске, как Postgres поступает с идентификаторами без кавычек.
+//
+// В SQL имена всегда передаются через quoteIdentifier, в том числе в приведениях ::regclass,
+// поэтому при preserve поиск по каталогу не сворачивает регистр.
+const (
+	IdentifierCasePreserve = "preserve"
+	IdentifierCaseLower    = "lower"
+)
+
+// NormalizeIdentifier приводит имя таблицы или колонки к виду, в котором оно хранится в БД
+func NormalizeIdentifier(name string) string {
+	if GetConfig().IdentifierCase == IdentifierCaseLower {
+		return strings.ToLower(name)
+	}
+	return name
+}
@@ -0,0 +1,77 @@
+package initializers
+
+import "testing"
+
+// setTestConfig меняет текущие настройки на время теста
+func setTestConfig(t *testing.T, change func(cfg *Config)) {
+	t.Helper()
+	configMu.Lock()
+	previous := config
+	change(&config)
+	configMu.Unlock()
+	t.Cleanup(func() {
+		configMu.Lock()
+		config = previous
+		configMu.Unlock()
+	})
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := []struct {
+		policy string
+		name   string
+		want   string
+	}{
+		{IdentifierCaseLower, "MyTable", "mytable"},
+		{IdentifierCaseLower, "mytable", "mytable"},
+		{IdentifierCaseLower, "Order_Items_2", "order_items_2"},
+		{IdentifierCasePreserve, "MyTable", "MyTable"},
+		{IdentifierCasePreserve, "mytable", "mytable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy+"/"+tt.name, func(t *testing.T) {
+			setTestConfig(t, func(cfg *Config) { cfg.IdentifierCase = tt.policy })
+
+			got := NormalizeIdentifier(tt.name)
+			if got != tt.want {
+				t.Fatalf("NormalizeIdentifier(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+			// Повторная нормализация не меняет имя: поиск находит то, что было создано
+			if again := NormalizeIdentifier(got); again != got {
+				t.Fatalf("NormalizeIdentifier(%q) = %q, want %q", got, again, got)
+			}
+		})
+	}
+}
+
+func TestParseConfigIdentifierCase(t *testing.T) {
+	setRequiredEnv(t)
+
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{"", IdentifierCaseLower},
+		{"lower", IdentifierCaseLower},
+		{"PRESERVE", IdentifierCasePreserve},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("IDENTIFIER_CASE", tt.env)
+			cfg, err := ParseConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.IdentifierCase != tt.want {
+				t.Fatalf("IdentifierCase = %q, want %q", cfg.IdentifierCase, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("IDENTIFIER_CASE", "upper")
+		if _, err := ParseConfig(); err == nil {
+			t.Fatal("ParseConfig() accepted IDENTIFIER_CASE=upper")
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package initializers
+
+import (
+	"log"
+	"strings"
+
+	"gorm.io/gorm/logger"
+)
+
+// parseLogLevel преобразует DB_LOG_LEVEL (silent/error/warn/info) в уровень GORM
+func parseLogLevel(level string) logger.LogLevel {
+	switch strings.ToLower(level) {
+	case "silent":
+		return logger.Silent
+	case "error":
+		return logger.Error
+	case "info":
+		return logger.Info
+	default:
+		return logger.Warn
+	}
+}
+
+// NewGormLogger создает логгер SQL-запросов GORM. Уровень задается DB_LOG_LEVEL
+// (по умолчанию warn), порог медленных запросов — DB_SLOW_QUERY_MS.
+// Вывод идет в тот же стандартный логгер, что и остальные сообщения сервера
+func NewGormLogger() logger.Interface {
+	return logger.New(
+		log.New(log.Writer(), "[SQL] ", log.LstdFlags),
+		logger.Config{
+			SlowThreshold:             GetConfig().DBSlowQuery,
+			LogLevel:                  parseLogLevel(GetConfig().DBLogLevel),
+			IgnoreRecordNotFoundError: true,
+			Colorful:                  false,
+		},
+	)
+}
@@ -0,0 +1,111 @@
+package initializers
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"server/model"
+)
+
+// ColumnRename — колонка доменной модели, созданная в старых БД с ошибкой в имени
+type ColumnRename struct {
+	Model interface{}
+	Old   string
+	New   string
+}
+
+// Table возвращает имя таблицы модели
+func (r ColumnRename) Table(db *gorm.DB) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(r.Model); err != nil {
+		return "", err
+	}
+	return stmt.Schema.Table, nil
+}
+
+// DomainColumnRenames — колонки, переименованные в model: теги gorm указывают новые имена
+var DomainColumnRenames = []ColumnRename{
+	{Model: &model.Production{}, Old: "employes_id", New: "employee_id"},
+	{Model: &model.Delivery{}, Old: "employes_id", New: "employee_id"},
+	{Model: &model.Transport{}, Old: "year_of_future", New: "year_of_issue"},
+}
+
+// LegacyViewName возвращает имя представления, в котором колонки таблицы доступны под старыми именами
+func LegacyViewName(table string) string {
+	return table + "_legacy"
+}
+
+// CreateLegacyView пересоздает представление <table>_legacy: все колонки таблицы, а колонки
+// из DomainColumnRenames — под старыми именами. Клиенты, еще не перешедшие на новые имена,
+// читают и пишут через него: представление из одних ссылок на колонки обновляемое.
+// Представление не видит колонки, добавленные позже, и мешает менять тип переименованных
+// колонок — после перехода клиентов его удаляют через DropLegacyView
+func CreateLegacyView(tx *gorm.DB, table string) error {
+	oldNames := map[string]string{}
+	for _, fix := range DomainColumnRenames {
+		fixTable, err := fix.Table(tx)
+		if err != nil {
+			return err
+		}
+		if fixTable == table {
+			oldNames[fix.New] = fix.Old
+		}
+	}
+
+	var columns []string
+	if err := tx.Raw(`
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ?
+		ORDER BY ordinal_position
+	`, table).Scan(&columns).Error; err != nil {
+		return err
+	}
+
+	selected := make([]string, len(columns))
+	for i, column := range columns {
+		selected[i] = tx.Statement.Quote(column)
+		if old, ok := oldNames[column]; ok {
+			selected[i] += " AS " + tx.Statement.Quote(old)
+		}
+	}
+
+	if err := DropLegacyView(tx, table); err != nil {
+		return err
+	}
+	return tx.Exec(fmt.Sprintf("CREATE VIEW %s AS SELECT %s FROM %s",
+		tx.Statement.Quote(LegacyViewName(table)), strings.Join(selected, ", "), tx.Statement.Quote(table))).Error
+}
+
+// DropLegacyView удаляет представление <table>_legacy, если оно есть
+func DropLegacyView(tx *gorm.DB, table string) error {
+	return tx.Exec("DROP VIEW IF EXISTS " + tx.Statement.Quote(LegacyViewName(table))).Error
+}
+
+// migrateDomainColumns переименовывает колонки из DomainColumnRenames и создает для
+// переименованных таблиц представления со старыми именами (см. CreateLegacyView).
+// Выполняется при старте только с MIGRATE_DOMAIN_COLUMNS=true, иначе — через
+// /api/admin/migrations/domain-columns. Шаг идемпотентен: колонка переименовывается, только
+// если старое имя есть, а нового нет. Таблицы с обеими колонками не меняются — их значения
+// сводит FixDomainColumns
+func migrateDomainColumns(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		for _, fix := range DomainColumnRenames {
+			if !m.HasColumn(fix.Model, fix.Old) || m.HasColumn(fix.Model, fix.New) {
+				continue
+			}
+			if err := m.RenameColumn(fix.Model, fix.Old, fix.New); err != nil {
+				return err
+			}
+			table, err := fix.Table(tx)
+			if err != nil {
+				return err
+			}
+			if err := CreateLegacyView(tx, table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
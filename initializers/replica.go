@@ -0,0 +1,118 @@
+package initializers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// DBNodeHeader — заголовок ответа со списком узлов БД, обслуживших запрос: primary, replica
+const DBNodeHeader = "X-DB-Node"
+
+// Узлы БД для DBNodeHeader
+const (
+	DBNodePrimary = "primary"
+	DBNodeReplica = "replica"
+)
+
+// dbReplica — пул реплики текущего подключения (nil, если реплика не задана или недоступна)
+var dbReplica *sql.DB
+
+// HasReplica сообщает, подключена ли реплика для чтения
+func HasReplica() bool {
+	dbMu.RLock()
+	defer dbMu.RUnlock()
+	return dbReplica != nil
+}
+
+// attachReplica подключает к db реплику для чтения через dbresolver: SELECT вне транзакций
+// уходят на реплику, запись и транзакции — на основной сервер. Недоступная реплика не мешает
+// работе: ошибка пишется в лог, и все запросы идут на основной сервер
+func attachReplica(db *gorm.DB, replicaURL string) *sql.DB {
+	if replicaURL == "" {
+		return nil
+	}
+
+	replica, err := sql.Open("pgx", replicaURL)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), healthPingTimeout)
+		err = replica.PingContext(ctx)
+		cancel()
+		if err != nil {
+			replica.Close()
+		}
+	}
+	if err != nil {
+		log.Printf("Warning: read replica is unavailable, reads go to primary: %v", err)
+		return nil
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{postgres.New(postgres.Config{Conn: replica})},
+	})
+	if err := db.Use(resolver); err != nil {
+		replica.Close()
+		log.Printf("Warning: failed to register read replica, reads go to primary: %v", err)
+		return nil
+	}
+
+	// Узел определяем по пулу, выбранному dbresolver: транзакции и запись остаются на основном
+	track := func(tx *gorm.DB) {
+		if tracker, ok := tx.Statement.Context.Value(dbNodeKey{}).(*dbNodeTracker); ok {
+			if tx.Statement.ConnPool == replica {
+				tracker.mark(DBNodeReplica)
+			} else {
+				tracker.mark(DBNodePrimary)
+			}
+		}
+	}
+	db.Callback().Query().After("gorm:query").Register("server:db_node", track)
+	db.Callback().Row().After("gorm:row").Register("server:db_node", track)
+	db.Callback().Raw().After("gorm:raw").Register("server:db_node", track)
+	db.Callback().Create().After("gorm:create").Register("server:db_node", track)
+	db.Callback().Update().After("gorm:update").Register("server:db_node", track)
+	db.Callback().Delete().After("gorm:delete").Register("server:db_node", track)
+
+	log.Println("Read replica attached")
+	return replica
+}
+
+// UsePrimary направляет все запросы db на основной сервер, даже если есть реплика
+func UsePrimary(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Write)
+}
+
+type dbNodeKey struct{}
+
+// dbNodeTracker копит узлы, обслужившие запросы, и пишет их в заголовок ответа
+type dbNodeTracker struct {
+	mu     sync.Mutex
+	header http.Header
+	nodes  []string
+}
+
+func (t *dbNodeTracker) mark(node string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, n := range t.nodes {
+		if n == node {
+			return
+		}
+	}
+	t.nodes = append(t.nodes, node)
+	// Заголовки, измененные после начала ответа, не отправляются — это допустимо для отладки
+	t.header.Set(DBNodeHeader, strings.Join(t.nodes, ","))
+}
+
+// WithDBNodeTracking сохраняет в контексте заголовки ответа, куда будут записаны узлы БД,
+// выполнившие запросы (DBNodeHeader). Работает, только когда подключена реплика
+func WithDBNodeTracking(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, dbNodeKey{}, &dbNodeTracker{header: header})
+}
@@ -0,0 +1,29 @@
+package initializers
+
+import (
+	"strings"
+)
+
+// defaultFieldValidators — проверки, если FIELD_VALIDATORS не задан
+const defaultFieldValidators = "employees.phone_number:phone"
+
+// FieldValidators разбирает FIELD_VALIDATORS: элементы "таблица.колонка:проверка" через запятую,
+// например "employees.phone_number:phone,employees.email:email". Значение "none" отключает проверки.
+// Возвращает таблица -> колонка -> проверка
+func FieldValidators() map[string]map[string]string {
+	raw := GetConfig().FieldValidators
+
+	result := map[string]map[string]string{}
+	for _, item := range strings.Split(raw, ",") {
+		target, kind, found := strings.Cut(strings.TrimSpace(item), ":")
+		table, column, dotted := strings.Cut(target, ".")
+		if !found || !dotted || table == "" || column == "" {
+			continue
+		}
+		if result[table] == nil {
+			result[table] = map[string]string{}
+		}
+		result[table][column] = strings.TrimSpace(kind)
+	}
+	return result
+}
@@ -36,11 +36,23 @@ type Backup struct {
 }
 
 type TableMeta struct {
-	ID        uint   `gorm:"primaryKey"`
-	Name      string `gorm:"uniqueIndex;size:255;not null"`
-	Columns   string `gorm:"type:text;not null"` // Сохраняем как JSON строку
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID            uint   `gorm:"primaryKey"`
+	Name          string `gorm:"uniqueIndex;size:255;not null"`
+	Columns       string `gorm:"type:text;not null"`     // Сохраняем как JSON строку
+	ColumnOptions string `gorm:"type:text"`              // JSON: имя колонки -> ColumnOptions
+	ColumnOrder   string `gorm:"type:text"`              // JSON массив: порядок отображения колонок
+	Sensitive     bool   `gorm:"not null;default:false"` // Логировать чтение таблицы в access_logs
+	Timestamps    bool   `gorm:"not null;default:false"` // Есть колонки created_at/updated_at с триггером
+	Audit         bool   `gorm:"not null;default:false"` // Записывать изменения строк в audit_logs
+	DefaultSort   string `gorm:"size:255"`               // Сортировка строк по умолчанию: "column" или "column:desc"
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// ColumnOptions хранит настройки колонки, заданные через API
+type ColumnOptions struct {
+	Default  *string `json:"default,omitempty"`
+	Nullable *bool   `json:"nullable,omitempty"`
 }
 
 // Преобразуем колонки в JSON перед сохранением
@@ -48,9 +60,45 @@ func (t *TableMeta) BeforeSave(tx *gorm.DB) (err error) {
 	if t.Columns == "" {
 		t.Columns = "[]"
 	}
+	if t.ColumnOptions == "" {
+		t.ColumnOptions = "{}"
+	}
 	return
 }
 
+// AccessLog — запись о чтении чувствительной таблицы
+type AccessLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	User      string    `gorm:"size:255;not null" json:"user"`
+	TableName string    `gorm:"size:255;not null;index" json:"table"`
+	Action    string    `gorm:"size:32;not null" json:"action"` // "read", "export", "backup"
+	RowCount  int       `json:"rowCount"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+// AuditLog — запись об изменении строки таблицы
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	User      string    `gorm:"size:255;not null" json:"user"`
+	TableName string    `gorm:"size:255;not null;index" json:"table"`
+	Operation string    `gorm:"size:16;not null" json:"operation"` // "insert", "update", "delete"; массовые: "bulk_update", "delete_all", "restore", "ingest", "copy"
+	RowID     string    `gorm:"size:255" json:"rowId"`
+	Changes   string    `gorm:"type:text" json:"-"` // JSON: колонка -> новое значение
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+// IdempotencyKey — сохраненный ответ на запрос с заголовком Idempotency-Key
+type IdempotencyKey struct {
+	ID          uint      `gorm:"primaryKey"`
+	Key         string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_key_route"`
+	Route       string    `gorm:"size:512;not null;uniqueIndex:idx_idempotency_key_route"` // Метод и путь запроса
+	Fingerprint string    `gorm:"size:64;not null;default:''"`                             // SHA-256 тела запроса
+	Status      int       `gorm:"not null"`                                                // 0 — запрос еще выполняется
+	Body        string    `gorm:"type:text"`
+	BodyGzip    []byte    `gorm:"type:bytea"` // Сжатое тело вместо Body для больших ответов
+	CreatedAt   time.Time `gorm:"index"`
+}
+
 type SavedQuery struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	Query     string    `gorm:"type:text;not null" json:"query"`
@@ -60,73 +108,77 @@ type SavedQuery struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 type Employee struct {
-	EmployeeID  int     `gorm:"column:employee_id;primaryKey"`
-	FullName    string  `gorm:"column:full_name"`
-	Position    string  `gorm:"column:position"`
-	Salary      float64 `gorm:"column:salary"`
-	PhoneNumber string  `gorm:"column:phone_number"`
+	EmployeeID  int     `gorm:"column:employee_id;primaryKey" json:"employeeId"`
+	FullName    string  `gorm:"column:full_name" json:"fullName"`
+	Position    string  `gorm:"column:position" json:"position"`
+	Salary      float64 `gorm:"column:salary" json:"salary"`
+	PhoneNumber string  `gorm:"column:phone_number" json:"phoneNumber"`
 }
 
 type Production struct {
-	ProductionID      int       `gorm:"column:production_id;primaryKey"`
-	ProductionDate    time.Time `gorm:"column:production_date"`
-	EmployeeID        int       `gorm:"column:employes_id"` // Вот тут ОШИБКА в твоей БД (employes вместо employees)
-	DefectiveQuantity int       `gorm:"column:defective_quantity"`
-	FactoryAddress    string    `gorm:"column:factory_address"`
+	ProductionID      int       `gorm:"column:production_id;primaryKey" json:"productionId"`
+	ProductionDate    time.Time `gorm:"column:production_date" json:"productionDate"`
+	EmployeeID        int       `gorm:"column:employee_id" json:"employeeId"` // В старых БД — employes_id, переименовывается в Migrate
+	DefectiveQuantity int       `gorm:"column:defective_quantity" json:"defectiveQuantity"`
+	FactoryAddress    string    `gorm:"column:factory_address" json:"factoryAddress"`
 }
 
 type Delivery struct {
-	DeliveryID   int       `gorm:"column:delivery_id;primaryKey"`
-	Amount       float64   `gorm:"column:amount"`
-	Address      string    `gorm:"column:address"`
-	Quantity     int       `gorm:"column:quantity"`
-	ProductType  string    `gorm:"column:product_type"`
-	DeliveryDate time.Time `gorm:"column:delivery_date"`
-	EmployeeID   int       `gorm:"column:employes_id"` // Тут та же ошибка
-	TransportID  int       `gorm:"column:transport_id"`
-	SalesPointID int       `gorm:"column:sales_point_id"`
+	DeliveryID   int       `gorm:"column:delivery_id;primaryKey" json:"deliveryId"`
+	Amount       float64   `gorm:"column:amount" json:"amount"`
+	Address      string    `gorm:"column:address" json:"address"`
+	Quantity     int       `gorm:"column:quantity" json:"quantity"`
+	ProductType  string    `gorm:"column:product_type" json:"productType"`
+	DeliveryDate time.Time `gorm:"column:delivery_date" json:"deliveryDate"`
+	EmployeeID   int       `gorm:"column:employee_id" json:"employeeId"` // В старых БД — employes_id
+	TransportID  int       `gorm:"column:transport_id" json:"transportId"`
+	SalesPointID int       `gorm:"column:sales_point_id" json:"salesPointId"`
 }
 
 type Transport struct {
-	TransportID     int       `gorm:"column:transport_id;primaryKey"`
-	Brand           string    `gorm:"column:brand"`
-	Year            int       `gorm:"column:year"`
-	MaintenanceDate time.Time `gorm:"column:maintenance_date"`
-	FuelType        string    `gorm:"column:fuel_type"`
-	YearOfIssue     int       `gorm:"column:year_of_future"` // Тут странное название в БД
-	EmployeeID      int       `gorm:"column:employee_id"`
+	TransportID     int       `gorm:"column:transport_id;primaryKey" json:"transportId"`
+	Brand           string    `gorm:"column:brand" json:"brand"`
+	Year            int       `gorm:"column:year" json:"year"`
+	MaintenanceDate time.Time `gorm:"column:maintenance_date" json:"maintenanceDate"`
+	FuelType        string    `gorm:"column:fuel_type" json:"fuelType"`
+	YearOfIssue     int       `gorm:"column:year_of_issue" json:"yearOfIssue"` // В старых БД — year_of_future
+	EmployeeID      int       `gorm:"column:employee_id" json:"employeeId"`
 }
 
 type SalesPoint struct {
-	SalesPointID  int       `gorm:"column:sales_point_id;primaryKey"`
-	ClientType    string    `gorm:"column:client_type"`
-	ShippingTime  time.Time `gorm:"column:shipping_time"`
-	RecipientName string    `gorm:"column:recipient_name"`
-	Address       string    `gorm:"column:address"`
+	SalesPointID  int       `gorm:"column:sales_point_id;primaryKey" json:"salesPointId"`
+	ClientType    string    `gorm:"column:client_type" json:"clientType"`
+	ShippingTime  time.Time `gorm:"column:shipping_time" json:"shippingTime"`
+	RecipientName string    `gorm:"column:recipient_name" json:"recipientName"`
+	Address       string    `gorm:"column:address" json:"address"`
 }
 
 type Product struct {
-	ProductID   int     `gorm:"column:product_id;primaryKey"`
-	BatchNumber string  `gorm:"column:batch_number"`
-	ProductType string  `gorm:"column:product_type"`
-	CostPrice   float64 `gorm:"column:cost_price"`
-	Name        string  `gorm:"column:name"`
+	ProductID   int     `gorm:"column:product_id;primaryKey" json:"productId"`
+	BatchNumber string  `gorm:"column:batch_number" json:"batchNumber"`
+	ProductType string  `gorm:"column:product_type" json:"productType"`
+	CostPrice   float64 `gorm:"column:cost_price" json:"costPrice"`
+	Name        string  `gorm:"column:name" json:"name"`
+
+	// Связи через таблицы product_deliveries и product_materials
+	Deliveries []Delivery `gorm:"many2many:product_deliveries;joinForeignKey:ProductID;joinReferences:DeliveryID" json:"deliveries,omitempty"`
+	Materials  []Material `gorm:"many2many:product_materials;joinForeignKey:ProductID;joinReferences:MaterialID" json:"materials,omitempty"`
 }
 
 type Material struct {
-	MaterialID        int     `gorm:"column:material_id;primaryKey"`
-	Price             float64 `gorm:"column:price"`
-	Type              string  `gorm:"column:type"`
-	Quantity          int     `gorm:"column:quantity"`
-	StorageDepartment string  `gorm:"column:storage_department"`
+	MaterialID        int     `gorm:"column:material_id;primaryKey" json:"materialId"`
+	Price             float64 `gorm:"column:price" json:"price"`
+	Type              string  `gorm:"column:type" json:"type"`
+	Quantity          int     `gorm:"column:quantity" json:"quantity"`
+	StorageDepartment string  `gorm:"column:storage_department" json:"storageDepartment"`
 }
 
 type ProductDelivery struct {
-	ProductID  int `gorm:"column:product_id"`
-	DeliveryID int `gorm:"column:delivery_id"`
+	ProductID  int `gorm:"column:product_id" json:"productId"`
+	DeliveryID int `gorm:"column:delivery_id" json:"deliveryId"`
 }
 
 type ProductMaterial struct {
-	ProductID  int `gorm:"column:product_id"`
-	MaterialID int `gorm:"column:material_id"`
+	ProductID  int `gorm:"column:product_id" json:"productId"`
+	MaterialID int `gorm:"column:material_id" json:"materialId"`
 }